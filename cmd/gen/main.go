@@ -4,6 +4,7 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -40,14 +41,10 @@ func main() {
 	}
 
 	var pkgs []stdlib.Package
+	var embeds []embedding
 
 	for _, dir := range dirs {
 		for _, lib := range walkLib(dir) {
-			symbols := []stdlib.Symbol{}
-			for _, file := range walkPkg(lib) {
-				symbols = append(symbols, getSymbols(file)...)
-			}
-
 			// convert to import path:
 			// get path relative to dir, and convert separators to slashes.
 			ip := strings.ReplaceAll(
@@ -55,6 +52,13 @@ func main() {
 				string(filepath.Separator), "/",
 			)
 
+			symbols := []stdlib.Symbol{}
+			for _, file := range walkPkg(lib) {
+				found, embedded := getSymbols(file, ip)
+				symbols = append(symbols, found...)
+				embeds = append(embeds, embedded...)
+			}
+
 			pkgs = append(pkgs, stdlib.Package{
 				Name:       filepath.Base(lib),
 				ImportPath: ip,
@@ -63,6 +67,7 @@ func main() {
 		}
 	}
 
+	expandInterfaces(pkgs, embeds)
 	saveSymbols(pkgs, *storageFormat)
 }
 
@@ -117,8 +122,17 @@ func walkPkg(path string) []string {
 	return files
 }
 
-func getSymbols(source string) []stdlib.Symbol {
+// An embedding records the interfaces embedded in an exported interface, so
+// that we can expand its method set once every package has been indexed.
+type embedding struct {
+	pkg   string   // the import path of the interface's package
+	name  string   // the interface's name
+	types []string // the embedded interfaces, qualified by their import path
+}
+
+func getSymbols(source, importPath string) ([]stdlib.Symbol, []embedding) {
 	var symbols []stdlib.Symbol
+	var embeds []embedding
 
 	// Create a FileSet to work with.
 	fset := token.NewFileSet()
@@ -135,6 +149,22 @@ func getSymbols(source string) []stdlib.Symbol {
 	}
 	text := string(bsrc)
 
+	imports := map[string]string{}
+	for _, spec := range file.Imports {
+		path := strings.Trim(spec.Path.Value, `"`)
+		if spec.Name != nil {
+			imports[spec.Name.Name] = path
+		} else {
+			imports[filepath.Base(path)] = path
+		}
+	}
+
+	// Embedded interfaces are collected first, since trimming drops the
+	// unexported ones (e.g., `error`).
+	for _, decl := range file.Decls {
+		embeds = append(embeds, embeddedInterfaces(decl, importPath, imports)...)
+	}
+
 	// Trim AST to exported declarations only.
 	ast.FileExports(file)
 
@@ -155,7 +185,7 @@ func getSymbols(source string) []stdlib.Symbol {
 		return true
 	})
 
-	return symbols
+	return symbols, embeds
 }
 
 func saveSymbols(pkgs []stdlib.Package, format string) {
@@ -197,28 +227,43 @@ func toGob(pkgs []stdlib.Package) {
 func declaration(n ast.Node, source string) []stdlib.Symbol {
 	sym, _ := n.(*ast.GenDecl)
 
+	var symbols []stdlib.Symbol
 	for _, spec := range sym.Specs {
 		switch t := spec.(type) { //nolint:gocritic
 		case *ast.TypeSpec:
-			return []stdlib.Symbol{{
+			doc := sym.Doc
+			if t.Doc != nil {
+				doc = t.Doc
+			}
+
+			symbols = append(symbols, stdlib.Symbol{
 				Name:      t.Name.Name,
-				Doc:       sym.Doc.Text(),
+				Doc:       doc.Text(),
 				Signature: strings.Split(source[t.Pos()-1:t.End()-1], " {")[0],
 				Kind:      typeName(*t),
-			}}
+				Methods:   interfaceMethods(t, source),
+			})
 		}
 	}
 
-	return nil
+	return symbols
 }
 
 func function(n ast.Node, source string) []stdlib.Symbol {
 	sym, _ := n.(*ast.FuncDecl)
+
+	recv := ""
+	if sym.Recv != nil && len(sym.Recv.List) > 0 {
+		t := sym.Recv.List[0].Type
+		recv = source[t.Pos()-1 : t.End()-1]
+	}
+
 	return []stdlib.Symbol{{
 		Name:      sym.Name.Name,
 		Doc:       sym.Doc.Text(),
 		Signature: strings.Split(source[sym.Pos()-1:sym.End()-1], " {")[0],
 		Kind:      "func",
+		Recv:      recv,
 	}}
 
 	// sym.Recv != nil
@@ -234,6 +279,34 @@ func function(n ast.Node, source string) []stdlib.Symbol {
 	// fmt.Println(sym.Name.Name, "(", ident.Name, ")")
 }
 
+// interfaceMethods records the method set of an interface type, so that we
+// can find its implementations.
+//
+// Embedded interfaces are added later, by `expandInterfaces`.
+func interfaceMethods(t *ast.TypeSpec, source string) []stdlib.Method {
+	iface, ok := t.Type.(*ast.InterfaceType)
+	if !ok {
+		return nil
+	}
+
+	methods := []stdlib.Method{}
+	for _, field := range iface.Methods.List {
+		fn, isFunc := field.Type.(*ast.FuncType)
+		if !isFunc {
+			continue
+		}
+
+		for _, name := range field.Names {
+			methods = append(methods, stdlib.Method{
+				Name:      name.Name,
+				Signature: name.Name + source[fn.Params.Pos()-1:fn.End()-1],
+			})
+		}
+	}
+
+	return methods
+}
+
 func typeName(t ast.TypeSpec) string {
 	switch t.Type.(type) {
 	case *ast.StructType:
@@ -250,3 +323,111 @@ func typeName(t ast.TypeSpec) string {
 		return "type"
 	}
 }
+
+// embeddedInterfaces returns the interfaces embedded in the exported
+// interface types of the given declaration.
+func embeddedInterfaces(n ast.Node, importPath string, imports map[string]string) []embedding {
+	sym, isGen := n.(*ast.GenDecl)
+	if !isGen {
+		return nil
+	}
+
+	var embeds []embedding
+	for _, spec := range sym.Specs {
+		t, ok := spec.(*ast.TypeSpec)
+		if !ok || !t.Name.IsExported() {
+			continue
+		}
+
+		iface, isIface := t.Type.(*ast.InterfaceType)
+		if !isIface {
+			continue
+		}
+
+		types := []string{}
+		for _, field := range iface.Methods.List {
+			if len(field.Names) > 0 {
+				continue
+			}
+
+			switch e := field.Type.(type) {
+			case *ast.Ident:
+				types = append(types, importPath+"."+e.Name)
+			case *ast.SelectorExpr:
+				if x, isIdent := e.X.(*ast.Ident); isIdent {
+					types = append(types, imports[x.Name]+"."+e.Sel.Name)
+				}
+			}
+		}
+
+		if len(types) > 0 {
+			embeds = append(embeds, embedding{pkg: importPath, name: t.Name.Name, types: types})
+		}
+	}
+
+	return embeds
+}
+
+// expandInterfaces adds the methods of embedded interfaces to the method
+// sets of the interfaces that embed them.
+//
+// We panic if an interface that embeds others still has no methods, since
+// that would silently break go-to-implementation for it.
+func expandInterfaces(pkgs []stdlib.Package, embeds []embedding) {
+	ifaces := map[string]*stdlib.Symbol{}
+	for i := range pkgs {
+		for j := range pkgs[i].Symbols {
+			sym := &pkgs[i].Symbols[j]
+			if sym.Kind == "interface" {
+				ifaces[pkgs[i].ImportPath+"."+sym.Name] = sym
+			}
+		}
+	}
+
+	byName := map[string][]string{}
+	for _, e := range embeds {
+		byName[e.pkg+"."+e.name] = append(byName[e.pkg+"."+e.name], e.types...)
+	}
+
+	var expand func(name string, seen map[string]bool) []stdlib.Method
+	expand = func(name string, seen map[string]bool) []stdlib.Method {
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+
+		if strings.HasSuffix(name, ".error") && ifaces[name] == nil {
+			return []stdlib.Method{{Name: "Error", Signature: "Error() string"}}
+		}
+
+		sym := ifaces[name]
+		if sym == nil {
+			fmt.Fprintf(os.Stderr, "warning: can't find embedded interface %s\n", name)
+			return nil
+		}
+
+		methods := append([]stdlib.Method{}, sym.Methods...)
+		for _, embedded := range byName[name] {
+			methods = append(methods, expand(embedded, seen)...)
+		}
+		return methods
+	}
+
+	for name := range byName {
+		sym := ifaces[name]
+
+		methods := []stdlib.Method{}
+		known := map[string]bool{}
+		for _, m := range expand(name, map[string]bool{}) {
+			if !known[m.Name] {
+				known[m.Name] = true
+				methods = append(methods, m)
+			}
+		}
+
+		if len(methods) == 0 {
+			panic(fmt.Sprintf("no methods recorded for interface %s", name))
+		}
+		sym.Methods = methods
+	}
+}
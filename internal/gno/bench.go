@@ -0,0 +1,75 @@
+package gno
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// benchRe matches a line of benchmark output:
+//
+// BenchmarkName-8   1000000   1234 ns/op   256 B/op   3 allocs/op
+var benchRe = regexp.MustCompile(
+	`(?m)^(Benchmark\S*?)(?:-\d+)?\s+(\d+)\s+([\d.]+) ns/op(?:\s+(\d+) B/op)?(?:\s+(\d+) allocs/op)?`)
+
+// BenchResult is a single result of a `gno test -bench` run.
+type BenchResult struct {
+	Name        string  `json:"name"`
+	N           int     `json:"n"`
+	NsPerOp     float64 `json:"nsPerOp"`
+	BytesPerOp  int64   `json:"bytesPerOp"`
+	AllocsPerOp int64   `json:"allocsPerOp"`
+}
+
+// RunBench runs Gno benchmarks:
+//
+// gno test -timeout 30s -run ^$ -bench ^(BenchmarkA|BenchmarkB)$ -benchmem -count <count> [args] <pkg_path>
+//
+// No names runs all of the package's benchmarks.
+func (m *BinManager) RunBench(pkg string, names []string, count int, opts TestOptions) ([]byte, error) {
+	cmd := opts.command(
+		m.gno,
+		pkg,
+		"-run",
+		"^$",
+		"-bench",
+		namePattern(names),
+		"-benchmem",
+		"-count",
+		strconv.Itoa(max(count, 1)),
+	)
+	return cmd.CombinedOutput()
+}
+
+// ParseBench extracts the results from the output of `RunBench`.
+func ParseBench(output string) []BenchResult {
+	results := []BenchResult{}
+
+	for _, m := range benchRe.FindAllStringSubmatch(output, -1) {
+		n, _ := strconv.Atoi(m[2])
+		ns, _ := strconv.ParseFloat(m[3], 64)
+
+		result := BenchResult{Name: m[1], N: n, NsPerOp: ns}
+		if m[4] != "" {
+			result.BytesPerOp, _ = strconv.ParseInt(m[4], 10, 64)
+		}
+		if m[5] != "" {
+			result.AllocsPerOp, _ = strconv.ParseInt(m[5], 10, 64)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// String formats the result like `go test -bench` does.
+func (r BenchResult) String() string {
+	return strings.Join([]string{
+		r.Name,
+		strconv.Itoa(r.N),
+		strconv.FormatFloat(r.NsPerOp, 'f', -1, 64) + " ns/op",
+		strconv.FormatInt(r.BytesPerOp, 10) + " B/op",
+		strconv.FormatInt(r.AllocsPerOp, 10) + " allocs/op",
+	}, "\t")
+}
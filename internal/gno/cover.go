@@ -0,0 +1,64 @@
+package gno
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// file.gno:10.2,12.3 1 1
+var coverBlockRe = regexp.MustCompile(`^(.+\.gno):(\d+)\.(\d+),(\d+)\.(\d+) (\d+) (\d+)$`)
+
+// CoverBlock is a block of a coverage profile. Lines and columns are
+// 1-based; columns are in bytes.
+type CoverBlock struct {
+	File      string
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	NumStmt   int
+	Count     int
+}
+
+// ParseCoverProfile parses a coverage profile (as written by
+// `gno test -coverprofile`). Repeated blocks are merged, as happens with
+// `-count` or the `atomic` mode.
+func ParseCoverProfile(profile string) []CoverBlock {
+	blocks := []CoverBlock{}
+	index := map[string]int{}
+
+	for _, line := range strings.Split(profile, "\n") {
+		m := coverBlockRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue // e.g., `mode: set`
+		}
+
+		ints := make([]int, 0, 6)
+		for _, s := range m[2:] {
+			n, _ := strconv.Atoi(s)
+			ints = append(ints, n)
+		}
+
+		block := CoverBlock{
+			File:      m[1],
+			StartLine: ints[0],
+			StartCol:  ints[1],
+			EndLine:   ints[2],
+			EndCol:    ints[3],
+			NumStmt:   ints[4],
+			Count:     ints[5],
+		}
+
+		key := strings.Join(m[1:6], ":")
+		if i, seen := index[key]; seen {
+			blocks[i].Count += block.Count
+			continue
+		}
+
+		index[key] = len(blocks)
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
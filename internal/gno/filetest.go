@@ -0,0 +1,154 @@
+package gno
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Output diff:, Error mismatch:, ...
+	filetestSectionRe = regexp.MustCompile(`(?i)^\s*(Output|Error|Realm|Events)\s+(?:diff|mismatch)`)
+	// The directives that start a golden block: `// Output:`.
+	filetestDirectiveRe = regexp.MustCompile(`^//\s*(Output|Error|Realm|Events):\s*$`)
+)
+
+// FiletestMismatch is a difference between the expected and actual results
+// of a filetest.
+type FiletestMismatch struct {
+	Directive string `json:"directive"` // "Output", "Error", ...; empty if unknown
+	Diff      string `json:"diff"`
+}
+
+// FiletestDirective is a golden block of a filetest: a directive comment
+// and the comment lines that follow it.
+type FiletestDirective struct {
+	Name      string
+	StartLine int // 0-based, the directive itself
+	EndLine   int // 0-based, inclusive
+}
+
+// RunFiletest runs a single filetest:
+//
+// gno test -timeout 30s -v -run file/^name_filetest.gno$ [args] <pkg_path>
+func (m *BinManager) RunFiletest(file string, opts TestOptions) ([]byte, error) {
+	return opts.command(m.gno, pkgFromFile(file), "-v", "-run", filetestPattern(file)).CombinedOutput()
+}
+
+// UpdateFiletest runs a filetest with the given content and returns that
+// content with its golden blocks updated from the actual results.
+//
+// The filetest is run from a copy of its package, so that the file itself
+// (which may be open, and modified, in an editor) is left untouched.
+func (m *BinManager) UpdateFiletest(file, content string, opts TestOptions) (string, []byte, error) {
+	dir, err := os.MkdirTemp("", "gnols-filetest")
+	if err != nil {
+		return content, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err = copyPackage(pkgFromFile(file), dir); err != nil {
+		return content, nil, err
+	}
+
+	copied := filepath.Join(dir, filepath.Base(file))
+	if err = os.WriteFile(copied, []byte(content), 0o600); err != nil {
+		return content, nil, err
+	}
+
+	out, err := opts.command(m.gno, dir, "-v", "-update-golden-tests", "-run", filetestPattern(file)).CombinedOutput()
+
+	updated, readErr := os.ReadFile(copied)
+	if readErr != nil {
+		return content, out, readErr
+	}
+	return string(updated), out, err
+}
+
+// copyPackage copies the files of a package (but not its subdirectories)
+// into another directory.
+func copyPackage(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		data, readErr := os.ReadFile(filepath.Join(src, entry.Name()))
+		if readErr != nil {
+			return readErr
+		}
+
+		if err = os.WriteFile(filepath.Join(dst, entry.Name()), data, 0o600); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filetestPattern matches only the given filetest; `gno test` names them
+// `file/<name>`, with each element of the pattern matched separately.
+func filetestPattern(file string) string {
+	return "file/^" + regexp.QuoteMeta(filepath.Base(file)) + "$"
+}
+
+// ParseFiletestOutput extracts the mismatches from the output of a failed
+// filetest. Output that doesn't look like a mismatch is reported as is.
+func ParseFiletestOutput(output string) []FiletestMismatch {
+	mismatches := []FiletestMismatch{}
+
+	var current *FiletestMismatch
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := filetestSectionRe.FindStringSubmatch(line); m != nil {
+			mismatches = append(mismatches, FiletestMismatch{Directive: capitalize(m[1])})
+			current = &mismatches[len(mismatches)-1]
+		} else if strings.HasPrefix(trimmed, "--- ") && !isDiffHeader(trimmed) {
+			current = nil
+		} else if current != nil && trimmed != "" {
+			current.Diff += trimmed + "\n"
+		}
+	}
+
+	if len(mismatches) == 0 {
+		if text := strings.TrimSpace(output); text != "" {
+			mismatches = append(mismatches, FiletestMismatch{Diff: text})
+		}
+	}
+
+	return mismatches
+}
+
+// FiletestDirectives returns the golden blocks of a filetest.
+func FiletestDirectives(content string) []FiletestDirective {
+	directives := []FiletestDirective{}
+
+	var current *FiletestDirective
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := filetestDirectiveRe.FindStringSubmatch(trimmed); m != nil {
+			directives = append(directives, FiletestDirective{Name: m[1], StartLine: i, EndLine: i})
+			current = &directives[len(directives)-1]
+		} else if current != nil && strings.HasPrefix(trimmed, "//") {
+			current.EndLine = i
+		} else {
+			current = nil
+		}
+	}
+
+	return directives
+}
+
+func isDiffHeader(line string) bool {
+	return strings.HasPrefix(line, "--- Expected") || strings.HasPrefix(line, "--- a/")
+}
+
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+}
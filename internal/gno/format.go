@@ -0,0 +1,162 @@
+package gno
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// The supported formatting tools.
+const (
+	FormatBuiltin   = "builtin"   // go/format
+	FormatGno       = "gnofmt"    // gno fmt <file>
+	FormatGoimports = "goimports" // goimports (stdin -> stdout)
+	FormatCustom    = "custom"    // any command (stdin -> stdout)
+)
+
+// DefaultFormatTimeout is how long an external formatter may run.
+const DefaultFormatTimeout = 5 * time.Second
+
+var ErrNoFormatCommand = errors.New("no command given for the custom formatter")
+
+// Formatter selects the tool used to format Gno files.
+type Formatter struct {
+	Tool    string        // one of the Format* constants
+	Command []string      // the command (and its arguments) for FormatCustom
+	Timeout time.Duration // the limit for external tools
+}
+
+// FormatError is returned when an external formatter fails.
+type FormatError struct {
+	Tool string
+	Msg  string
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Tool, e.Msg)
+}
+
+// SetFormatter changes the tool used by `Format`.
+//
+// External tools are resolved here, so that a missing binary is reported
+// when the settings change rather than on every format request.
+func (m *BinManager) SetFormatter(f Formatter) error {
+	if f.Timeout <= 0 {
+		f.Timeout = DefaultFormatTimeout
+	}
+
+	switch f.Tool {
+	case "", FormatBuiltin:
+		f.Tool = FormatBuiltin
+	case FormatGno:
+		f.Command = []string{m.gno, "fmt"}
+	case FormatGoimports:
+		bin, err := exec.LookPath("goimports")
+		if err != nil {
+			return err
+		}
+		f.Command = []string{bin}
+	case FormatCustom:
+		if len(f.Command) == 0 {
+			return ErrNoFormatCommand
+		}
+		bin, err := exec.LookPath(f.Command[0])
+		if err != nil {
+			return err
+		}
+		f.Command = append([]string{bin}, f.Command[1:]...)
+	default:
+		return fmt.Errorf("unknown formatter: %s", f.Tool)
+	}
+
+	m.formatter = f
+	return nil
+}
+
+// Format a Gno file using the configured formatter (`go/format` by default).
+func (m *BinManager) Format(gnoFile string) ([]byte, error) {
+	switch m.formatter.Tool {
+	case FormatGno:
+		return m.formatFile(gnoFile)
+	case FormatGoimports, FormatCustom:
+		return m.formatStdin(gnoFile)
+	default:
+		return format.Source([]byte(gnoFile))
+	}
+}
+
+// formatStdin pipes the content through the formatter's command.
+func (m *BinManager) formatStdin(gnoFile string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.formatter.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, m.formatter.Command[0], m.formatter.Command[1:]...) //nolint:gosec
+	cmd.Stdin = strings.NewReader(gnoFile)
+
+	out, err := m.runFormatter(ctx, cmd)
+	if err == nil && len(out) == 0 && gnoFile != "" {
+		return nil, &FormatError{Tool: m.formatter.Tool, Msg: "no output"}
+	}
+	return out, err
+}
+
+// formatFile formats a temporary copy of the content, since `gno fmt` only
+// accepts files. Its result is read from stdout or, if it rewrote the file
+// in place, from the file itself.
+func (m *BinManager) formatFile(gnoFile string) ([]byte, error) {
+	tmp, err := os.CreateTemp("", "gnols-*.gno")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(gnoFile)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.formatter.Timeout)
+	defer cancel()
+
+	args := append(append([]string{}, m.formatter.Command[1:]...), tmp.Name())
+	cmd := exec.CommandContext(ctx, m.formatter.Command[0], args...) //nolint:gosec
+
+	out, err := m.runFormatter(ctx, cmd)
+	if err == nil && len(out) == 0 {
+		return os.ReadFile(tmp.Name())
+	}
+	return out, err
+}
+
+// runFormatter runs the command, turning failures (including timeouts) into
+// a `FormatError` that carries the tool's own message.
+func (m *BinManager) runFormatter(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, &FormatError{
+			Tool: m.formatter.Tool,
+			Msg:  fmt.Sprintf("timed out after %s", m.formatter.Timeout),
+		}
+	} else if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, &FormatError{Tool: m.formatter.Tool, Msg: msg}
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"go/format"
 	"os/exec"
 	"regexp"
 
@@ -27,6 +26,8 @@ type BinManager struct {
 	gopls            string // path to gopls binary
 	shouldPrecompile bool   // whether to precompile on save
 	shouldBuild      bool   // whether to build on save
+
+	formatter Formatter // the tool used by `Format`
 }
 
 // BuildError is an error returned by the `gno build` command.
@@ -72,6 +73,7 @@ func NewBinManager(gno, gnokey string, precompile, build bool) (*BinManager, err
 		gopls:            gopls,
 		shouldPrecompile: precompile,
 		shouldBuild:      build,
+		formatter:        Formatter{Tool: FormatBuiltin},
 	}, nil
 }
 
@@ -87,13 +89,6 @@ func (m *BinManager) Gopls() string {
 	return m.gopls
 }
 
-// Format a Gno file using std formatter.
-//
-// TODO: support other tools?
-func (m *BinManager) Format(gnoFile string) ([]byte, error) {
-	return format.Source([]byte(gnoFile))
-}
-
 // Precompile a Gno package: gno precompile <dir>.
 func (m *BinManager) Precompile(gnoDir string) ([]byte, error) {
 	return exec.Command(m.gno, "precompile", gnoDir).CombinedOutput() //nolint:gosec
@@ -104,23 +99,6 @@ func (m *BinManager) Build(gnoDir string) ([]byte, error) {
 	return exec.Command(m.gno, "build", gnoDir).CombinedOutput() //nolint:gosec
 }
 
-// RunTest runs a Gno test:
-//
-// gno test -timeout 30s -run ^TestName$ <pkg_path>
-func (m *BinManager) RunTest(pkg, name string) ([]byte, error) {
-	cmd := exec.Command( //nolint:gosec
-		m.gno,
-		"test",
-		"-timeout",
-		"30s",
-		"-run",
-		fmt.Sprintf("^%s$", name),
-		pkg,
-	)
-	cmd.Dir = pkg
-	return cmd.CombinedOutput()
-}
-
 // Lint precompiles and builds a Gno package and returns any errors.
 //
 // In practice, this means:
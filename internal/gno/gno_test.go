@@ -1,7 +1,14 @@
 package gno_test
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jdkato/gnols/internal/gno"
 )
@@ -14,3 +21,255 @@ func TestNewManager(t *testing.T) {
 		t.Log("gno bin: not found")
 	}
 }
+
+func TestFormatCustom(t *testing.T) {
+	mgr, err := gno.NewBinManager("gno", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := "package a\n\nfunc A() {}\n"
+	if err = mgr.SetFormatter(gno.Formatter{Tool: gno.FormatCustom, Command: []string{"cat"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := mgr.Format(src)
+	if err != nil || string(out) != src {
+		t.Errorf("expected = %v, got = %v (%v)", src, string(out), err)
+	}
+
+	err = mgr.SetFormatter(gno.Formatter{
+		Tool:    gno.FormatCustom,
+		Command: []string{"sh", "-c", "echo 'bad input' >&2; exit 1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var formatErr *gno.FormatError
+	if _, err = mgr.Format(src); !errors.As(err, &formatErr) || formatErr.Msg != "bad input" {
+		t.Errorf("expected = %v, got = %v", "bad input", err)
+	}
+
+	err = mgr.SetFormatter(gno.Formatter{
+		Tool:    gno.FormatCustom,
+		Command: []string{"sleep", "5"},
+		Timeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = mgr.Format(src); !errors.As(err, &formatErr) || !strings.Contains(formatErr.Msg, "timed out") {
+		t.Errorf("expected = %v, got = %v", "timed out", err)
+	}
+}
+
+func TestSetFormatter(t *testing.T) {
+	mgr, err := gno.NewBinManager("gno", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mgr.SetFormatter(gno.Formatter{Tool: gno.FormatCustom}); !errors.Is(err, gno.ErrNoFormatCommand) {
+		t.Errorf("expected = %v, got = %v", gno.ErrNoFormatCommand, err)
+	}
+
+	if err = mgr.SetFormatter(gno.Formatter{Tool: "prettier"}); err == nil {
+		t.Errorf("expected = %v, got = %v", "error", err)
+	}
+}
+
+func TestParseBench(t *testing.T) {
+	output := `goos: linux
+BenchmarkAdd-8   	 1000000	      1234 ns/op	     256 B/op	       3 allocs/op
+BenchmarkSub     	     500	      12.5 ns/op
+PASS
+ok      ./foo   1.23s`
+
+	results := gno.ParseBench(output)
+	if len(results) != 2 {
+		t.Fatalf("expected = %v, got = %v", 2, len(results))
+	}
+
+	expected := gno.BenchResult{Name: "BenchmarkAdd", N: 1000000, NsPerOp: 1234, BytesPerOp: 256, AllocsPerOp: 3}
+	if results[0] != expected {
+		t.Errorf("expected = %v, got = %v", expected, results[0])
+	}
+
+	expected = gno.BenchResult{Name: "BenchmarkSub", N: 500, NsPerOp: 12.5}
+	if results[1] != expected {
+		t.Errorf("expected = %v, got = %v", expected, results[1])
+	}
+}
+
+func TestParseTestOutput(t *testing.T) {
+	output := `=== RUN   TestAdd
+--- PASS: TestAdd (0.00s)
+=== RUN   TestSub
+    math_test.gno:12: expected 1, got 2
+--- FAIL: TestSub (0.01s)
+=== RUN   TestSkip
+--- SKIP: TestSkip (0.00s)
+FAIL`
+
+	results := gno.ParseTestOutput(output)
+	if len(results) != 3 {
+		t.Fatalf("expected = %v, got = %v", 3, len(results))
+	}
+
+	if results[0].Status != "pass" || len(results[0].Failures) != 0 {
+		t.Errorf("expected = %v, got = %v", "pass", results[0])
+	}
+
+	sub := results[1]
+	if sub.Name != "TestSub" || sub.Status != "fail" || sub.Elapsed != "0.01s" {
+		t.Errorf("expected = %v, got = %v", "TestSub fail 0.01s", sub)
+	}
+
+	expected := gno.TestFailure{File: "math_test.gno", Line: 12, Msg: "expected 1, got 2"}
+	if len(sub.Failures) != 1 || sub.Failures[0] != expected {
+		t.Errorf("expected = %v, got = %v", expected, sub.Failures)
+	}
+
+	if results[2].Status != "skip" {
+		t.Errorf("expected = %v, got = %v", "skip", results[2].Status)
+	}
+}
+
+func TestParseFiletestOutput(t *testing.T) {
+	output := `=== RUN   file/z0_filetest.gno
+--- FAIL: file/z0_filetest.gno (0.01s)
+    Output diff:
+    --- Expected
+    +++ Actual
+    @@ -1 +1 @@
+    -41
+    +42
+FAIL`
+
+	mismatches := gno.ParseFiletestOutput(output)
+	if len(mismatches) != 1 || mismatches[0].Directive != "Output" {
+		t.Fatalf("expected = %v, got = %v", "Output", mismatches)
+	}
+
+	if !strings.Contains(mismatches[0].Diff, "-41\n+42") {
+		t.Errorf("expected = %v, got = %v", "-41\n+42", mismatches[0].Diff)
+	}
+
+	mismatches = gno.ParseFiletestOutput("panic: oops")
+	if len(mismatches) != 1 || mismatches[0].Directive != "" || mismatches[0].Diff != "panic: oops" {
+		t.Errorf("expected = %v, got = %v", "panic: oops", mismatches)
+	}
+}
+
+func TestFiletestDirectives(t *testing.T) {
+	content := "package main\n\n// Output:\n// 42\n// done\n\n// Error:\n// none\n"
+
+	expected := []gno.FiletestDirective{
+		{Name: "Output", StartLine: 2, EndLine: 4},
+		{Name: "Error", StartLine: 6, EndLine: 7},
+	}
+	if got := gno.FiletestDirectives(content); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected = %v, got = %v", expected, got)
+	}
+}
+
+func TestParseCoverProfile(t *testing.T) {
+	profile := `mode: set
+gno.land/p/demo/math/math.gno:3.24,5.2 1 1
+gno.land/p/demo/math/math.gno:7.24,9.2 1 0
+gno.land/p/demo/math/math.gno:3.24,5.2 1 2`
+
+	blocks := gno.ParseCoverProfile(profile)
+	if len(blocks) != 2 {
+		t.Fatalf("expected = %v, got = %v", 2, len(blocks))
+	}
+
+	expected := gno.CoverBlock{
+		File:      "gno.land/p/demo/math/math.gno",
+		StartLine: 3,
+		StartCol:  24,
+		EndLine:   5,
+		EndCol:    2,
+		NumStmt:   1,
+		Count:     3,
+	}
+	if blocks[0] != expected {
+		t.Errorf("expected = %v, got = %v", expected, blocks[0])
+	}
+}
+
+func TestUpdateFiletest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as `gno`")
+	}
+	dir := t.TempDir()
+
+	// A fake `gno test -update-golden-tests ... <pkg>`, which "updates" the
+	// filetest in the package it's given.
+	bin := filepath.Join(dir, "gno")
+	script := "#!/bin/sh\nfor a; do pkg=$a; done\nprintf 'package main\\n\\n// Output:\\n// 42\\n' > \"$pkg/z0_filetest.gno\"\n"
+	if err := os.WriteFile(bin, []byte(script), 0o700); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+
+	pkg := filepath.Join(dir, "pkg")
+	if err := os.Mkdir(pkg, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(pkg, "z0_filetest.gno")
+	original := "package main\n\n// Output:\n// 41\n"
+	if err := os.WriteFile(file, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr, err := gno.NewBinManager(bin, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, _, err := mgr.UpdateFiletest(file, "package main\n\n// unsaved\n\n// Output:\n// 41\n", gno.TestOptions{})
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(updated, "// 42") {
+		t.Errorf("expected = %v, got = %v", "// 42", updated)
+	}
+
+	onDisk, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(onDisk) != original {
+		t.Errorf("expected = %v, got = %v", original, string(onDisk))
+	}
+}
+
+func TestStreamTestNoCoverage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as `gno`")
+	}
+	dir := t.TempDir()
+
+	// A `gno` that predates `-coverprofile`.
+	bin := filepath.Join(dir, "gno")
+	script := "#!/bin/sh\necho 'flag provided but not defined: -coverprofile'\nexit 2\n"
+	if err := os.WriteFile(bin, []byte(script), 0o700); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+
+	mgr, err := gno.NewBinManager(bin, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := gno.TestOptions{CoverProfile: filepath.Join(dir, "cover.out")}
+	if _, err = mgr.StreamTest(dir, nil, opts, nil); !errors.Is(err, gno.ErrNoCoverage) {
+		t.Errorf("expected = %v, got = %v", gno.ErrNoCoverage, err)
+	}
+
+	// Without coverage, the failure is gno's own.
+	if _, err = mgr.StreamTest(dir, nil, gno.TestOptions{}, nil); err == nil || errors.Is(err, gno.ErrNoCoverage) {
+		t.Errorf("expected = %v, got = %v", "exit status 2", err)
+	}
+}
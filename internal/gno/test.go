@@ -0,0 +1,195 @@
+package gno
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// === RUN   TestName
+	testRunRe = regexp.MustCompile(`^\s*=== RUN\s+(\S+)`)
+	// --- FAIL: TestName (0.00s)
+	testResultRe = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+)(?: \(([^)]+)\))?`)
+	// foo_test.gno:12: expected 1, got 2
+	testFailureRe = regexp.MustCompile(`^\s+(\S+\.gno):(\d+): ?(.*)$`)
+)
+
+// ErrNoCoverage is returned when the gno binary doesn't support
+// `-coverprofile`, so that coverage can't be collected.
+var ErrNoCoverage = errors.New("this version of gno can't collect coverage (no -coverprofile flag)")
+
+// DefaultTestTimeout is the `-timeout` of test runs.
+const DefaultTestTimeout = 30 * time.Second
+
+// TestOptions configures a test run.
+type TestOptions struct {
+	Timeout      time.Duration // DefaultTestTimeout if not set
+	Args         []string      // extra flags, such as -root-dir or -print-runtime-metrics
+	Env          []string      // extra environment variables (KEY=value)
+	Dir          string        // the working directory; the package's if not set
+	CoverProfile string        // if set, the path to write a coverage profile to
+}
+
+// command returns the `gno test` command for the package, with the given
+// flags followed by the user's.
+func (o TestOptions) command(gno, pkg string, flags ...string) *exec.Cmd {
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTestTimeout
+	}
+
+	args := append([]string{"test", "-timeout", timeout.String()}, flags...)
+	if o.CoverProfile != "" {
+		args = append(args, "-coverprofile", o.CoverProfile)
+	}
+	args = append(args, o.Args...)
+	args = append(args, pkg)
+
+	cmd := exec.Command(gno, args...) //nolint:gosec
+
+	cmd.Dir = pkg
+	if o.Dir != "" {
+		cmd.Dir = o.Dir
+	}
+
+	if len(o.Env) > 0 {
+		cmd.Env = append(os.Environ(), o.Env...)
+	}
+
+	return cmd
+}
+
+// TestResult is the outcome of a single test (or subtest).
+type TestResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"` // "pass", "fail" or "skip"
+	Elapsed  string        `json:"elapsed,omitempty"`
+	Failures []TestFailure `json:"failures,omitempty"`
+}
+
+// TestFailure is a message logged by a test, such as a failed assertion.
+type TestFailure struct {
+	File string `json:"file"`
+	Line int    `json:"line"` // 1-based
+	Msg  string `json:"msg"`
+}
+
+// StreamTest runs Gno tests verbosely, calling onLine (if not nil) with each
+// line of their output as it's written:
+//
+// gno test -timeout 30s -v -run ^(TestA|TestB)$ [args] <pkg_path>
+//
+// A single subtest is run with -run ^TestA$/^case$. No names runs all of the
+// package's tests. It returns the complete output, and ErrNoCoverage if gno
+// rejected the coverage profile.
+func (m *BinManager) StreamTest(pkg string, names []string, opts TestOptions, onLine func(string)) ([]byte, error) {
+	cmd := opts.command(m.gno, pkg, "-v", "-run", namePattern(names))
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			out.WriteString(line + "\n")
+			if onLine != nil {
+				onLine(line)
+			}
+		}
+
+		// Don't block the command on a line we couldn't read.
+		_, _ = io.Copy(&out, pr)
+	}()
+
+	err := cmd.Run()
+	pw.Close()
+	<-done
+
+	if err != nil && opts.CoverProfile != "" &&
+		bytes.Contains(out.Bytes(), []byte("flag provided but not defined: -coverprofile")) {
+		err = ErrNoCoverage
+	}
+
+	return out.Bytes(), err
+}
+
+// namePattern returns the `-run` (or `-bench`) pattern matching exactly the
+// given names, or everything if there are none.
+//
+// A subtest (TestX/case) is matched level by level, since the pattern is
+// split on slashes: ^TestX$/^case$.
+func namePattern(names []string) string {
+	if len(names) == 0 {
+		return "."
+	} else if len(names) == 1 && strings.Contains(names[0], "/") {
+		parts := strings.Split(names[0], "/")
+		for i, part := range parts {
+			parts[i] = "^" + regexp.QuoteMeta(part) + "$"
+		}
+		return strings.Join(parts, "/")
+	}
+
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// ParseTestOutput extracts the results from the output of `gno test -v`.
+//
+// Logged messages are attached to the test that was last mentioned, since
+// they're either streamed after its `=== RUN` line or printed after its
+// result.
+func ParseTestOutput(output string) []TestResult {
+	results := []TestResult{}
+	index := map[string]int{}
+	failures := map[string][]TestFailure{}
+
+	current := ""
+	for _, line := range strings.Split(output, "\n") {
+		if m := testRunRe.FindStringSubmatch(line); m != nil {
+			current = m[1]
+		} else if m := testResultRe.FindStringSubmatch(line); m != nil {
+			current = m[2]
+			if _, seen := index[current]; !seen {
+				index[current] = len(results)
+				results = append(results, TestResult{Name: current})
+			}
+
+			r := &results[index[current]]
+			r.Status = strings.ToLower(m[1])
+			r.Elapsed = m[3]
+		} else if m := testFailureRe.FindStringSubmatch(line); m != nil && current != "" {
+			n, _ := strconv.Atoi(m[2])
+			failures[current] = append(failures[current], TestFailure{
+				File: m[1],
+				Line: n,
+				Msg:  m[3],
+			})
+		}
+	}
+
+	for i := range results {
+		if results[i].Status == "fail" {
+			results[i].Failures = failures[results[i].Name]
+		}
+	}
+
+	return results
+}
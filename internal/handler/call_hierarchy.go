@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func (h *handler) handlePrepareCallHierarchy(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.CallHierarchyPrepareParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	pkg, err := h.documents.LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	g, err := h.callGraph(doc.Path)
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	target, found := g.targetAt(pkg, doc.Path, docOffset(doc, params.Position))
+	if !found {
+		return reply(ctx, nil, nil)
+	}
+
+	item, found := g.items[target]
+	if !found {
+		return reply(ctx, nil, nil)
+	}
+
+	return reply(ctx, []protocol.CallHierarchyItem{item}, nil)
+}
+
+func (h *handler) handleIncomingCalls(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.CallHierarchyIncomingCallsParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	target, err := itemTarget(params.Item)
+	if err != nil {
+		return reply(ctx, nil, &jsonrpc2.Error{Code: jsonrpc2.InvalidParams, Message: err.Error()})
+	}
+
+	g, err := h.callGraph(uri.URI(params.Item.URI).Filename())
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	return reply(ctx, g.incoming(target), nil)
+}
+
+func (h *handler) handleOutgoingCalls(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.CallHierarchyOutgoingCallsParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	target, err := itemTarget(params.Item)
+	if err != nil {
+		return reply(ctx, nil, &jsonrpc2.Error{Code: jsonrpc2.InvalidParams, Message: err.Error()})
+	}
+
+	g, err := h.callGraph(uri.URI(params.Item.URI).Filename())
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	return reply(ctx, g.outgoing(target), nil)
+}
+
+// callGraph builds the call graph of the workspace, or of the given file's
+// package if there's no workspace.
+func (h *handler) callGraph(path string) (*callGraph, error) {
+	root := h.rootDir
+	if root == "" {
+		root = filepath.Dir(path)
+	}
+
+	pkgs, err := h.documents.LoadWorkspace(root)
+	if err != nil {
+		return nil, err
+	}
+
+	g := buildCallGraph(pkgs)
+	slog.Info("call_hierarchy", "packages", len(pkgs), "edges", len(g.edges))
+
+	return g, nil
+}
+
+// itemTarget decodes the target stored in an item's data.
+func itemTarget(item protocol.CallHierarchyItem) (callTarget, error) {
+	var target callTarget
+
+	data, err := json.Marshal(item.Data)
+	if err != nil {
+		return target, err
+	}
+
+	err = json.Unmarshal(data, &target)
+	return target, err
+}
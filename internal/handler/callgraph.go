@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"go/ast"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// A callTarget identifies a node in the call graph: a function or method, a
+// package-level variable, or an external function we don't have the source
+// of (e.g., `std.GetOrigCaller`).
+type callTarget struct {
+	Path   string `json:"path,omitempty"`   // the declaring file
+	Offset int    `json:"offset,omitempty"` // the byte offset of the declared name
+	Pkg    string `json:"pkg,omitempty"`    // the import path, for external targets
+	Name   string `json:"name"`
+}
+
+// A callEdge is a call (or a write, for variables) from one target to
+// another.
+type callEdge struct {
+	Caller callTarget
+	Callee callTarget
+	Site   protocol.Range // in the caller's file
+}
+
+// callGraph is the call graph of the non-test code in a workspace.
+type callGraph struct {
+	items map[callTarget]protocol.CallHierarchyItem
+	edges []callEdge
+
+	byImport map[string]*store.Package
+}
+
+// buildCallGraph computes the call graph of the given packages.
+//
+// Calls are resolved using each package's type information. Calls to other
+// workspace packages are resolved by import path (from `gno.mod`) and name;
+// calls to other packages are recorded as external targets.
+func buildCallGraph(pkgs []*store.Package) *callGraph {
+	g := &callGraph{
+		items:    map[callTarget]protocol.CallHierarchyItem{},
+		byImport: map[string]*store.Package{},
+	}
+
+	for _, pkg := range pkgs {
+		if pkg.ImportPath != "" {
+			g.byImport[pkg.ImportPath] = pkg
+		}
+		forEachFunc(pkg, func(_ *ast.File, fn *ast.FuncDecl) {
+			g.items[declTarget(pkg, fn.Name)] = funcItem(pkg, fn)
+		})
+	}
+
+	for _, pkg := range pkgs {
+		ti := pkg.TypeInfo()
+		forEachFunc(pkg, func(file *ast.File, fn *ast.FuncDecl) {
+			if fn.Body != nil {
+				g.addEdges(pkg, ti, file, declTarget(pkg, fn.Name), fn.Body)
+			}
+		})
+	}
+
+	return g
+}
+
+// forEachFunc calls fn for every function declared in the package's non-test
+// files.
+func forEachFunc(pkg *store.Package, fn func(*ast.File, *ast.FuncDecl)) {
+	for _, path := range pkg.Paths() {
+		if store.IsTestFile(path) {
+			continue
+		}
+
+		file := pkg.Files[path]
+		for _, decl := range file.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok {
+				fn(file, fd)
+			}
+		}
+	}
+}
+
+func (g *callGraph) addEdges(pkg *store.Package, ti *store.TypeInfo, file *ast.File, caller callTarget, body *ast.BlockStmt) {
+	add := func(callee callTarget, site ast.Node, item func() protocol.CallHierarchyItem) {
+		if _, ok := g.items[callee]; !ok {
+			g.items[callee] = item()
+		}
+		g.edges = append(g.edges, callEdge{Caller: caller, Callee: callee, Site: pkg.Range(site)})
+	}
+
+	written := func(expr ast.Expr) {
+		if v := packageVar(ti, expr); v != nil {
+			add(objTarget(pkg, v), expr, func() protocol.CallHierarchyItem {
+				return varItem(pkg, v)
+			})
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			callee, kind := g.resolveCallee(pkg, ti, file, node.Fun)
+			if callee == nil {
+				return true
+			}
+			site := calleeIdent(node.Fun)
+			add(*callee, site, func() protocol.CallHierarchyItem {
+				return externalItem(pkg, *callee, kind, site)
+			})
+		case *ast.AssignStmt:
+			for _, lhs := range node.Lhs {
+				written(lhs)
+			}
+		case *ast.IncDecStmt:
+			written(node.X)
+		}
+		return true
+	})
+}
+
+// resolveCallee returns the target of a call, along with its kind (used if
+// the target is external).
+func (g *callGraph) resolveCallee(pkg *store.Package, ti *store.TypeInfo, file *ast.File, fun ast.Expr) (*callTarget, protocol.SymbolKind) {
+	id := calleeIdent(fun)
+	if id == nil {
+		return nil, 0
+	}
+
+	if fn, ok := ti.Info.Uses[id].(*types.Func); ok {
+		if fn.Pkg() == ti.Pkg {
+			target := objTarget(pkg, fn)
+			return &target, 0
+		} else if sig, isSig := fn.Type().(*types.Signature); isSig && sig.Recv() != nil {
+			return &callTarget{Pkg: fn.Pkg().Path(), Name: fn.Name()}, protocol.SymbolKindMethod
+		} else if fn.Pkg() != nil {
+			return &callTarget{Pkg: fn.Pkg().Path(), Name: fn.Name()}, protocol.SymbolKindFunction
+		}
+	}
+
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, 0
+	}
+
+	if x, isIdent := sel.X.(*ast.Ident); isIdent && (ti.Info.Uses[x] == nil || isPkgName(ti, x)) {
+		specs := importsNamed(file, x.Name)
+		if len(specs) == 0 {
+			return nil, 0
+		}
+		path, _ := strconv.Unquote(specs[0].Path.Value)
+
+		if other, found := g.byImport[path]; found {
+			if target, declared := findFunc(other, sel.Sel.Name); declared {
+				return &target, 0
+			}
+		}
+		return &callTarget{Pkg: path, Name: sel.Sel.Name}, protocol.SymbolKindFunction
+	}
+
+	// A method call on package state (e.g., `tree.Set(...)`), where the
+	// method's type is unknown.
+	if ti.Info.Uses[id] == nil && packageVar(ti, sel.X) != nil {
+		return &callTarget{Pkg: pkg.ImportPath, Name: exprString(fun)}, protocol.SymbolKindMethod
+	}
+
+	return nil, 0
+}
+
+// incoming returns the calls made to the given target, grouped by caller.
+func (g *callGraph) incoming(target callTarget) []protocol.CallHierarchyIncomingCall {
+	calls := []protocol.CallHierarchyIncomingCall{}
+	index := map[callTarget]int{}
+
+	for _, edge := range g.edges {
+		if edge.Callee != target {
+			continue
+		}
+
+		i, ok := index[edge.Caller]
+		if !ok {
+			i = len(calls)
+			index[edge.Caller] = i
+			calls = append(calls, protocol.CallHierarchyIncomingCall{From: g.items[edge.Caller]})
+		}
+		calls[i].FromRanges = append(calls[i].FromRanges, edge.Site)
+	}
+
+	return calls
+}
+
+// outgoing returns the calls made by the given target, grouped by callee.
+func (g *callGraph) outgoing(target callTarget) []protocol.CallHierarchyOutgoingCall {
+	calls := []protocol.CallHierarchyOutgoingCall{}
+	index := map[callTarget]int{}
+
+	for _, edge := range g.edges {
+		if edge.Caller != target {
+			continue
+		}
+
+		i, ok := index[edge.Callee]
+		if !ok {
+			i = len(calls)
+			index[edge.Callee] = i
+			calls = append(calls, protocol.CallHierarchyOutgoingCall{To: g.items[edge.Callee]})
+		}
+		calls[i].FromRanges = append(calls[i].FromRanges, edge.Site)
+	}
+
+	return calls
+}
+
+// calleeIdent returns the identifier naming the called function.
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f
+	case *ast.SelectorExpr:
+		return f.Sel
+	case *ast.IndexExpr: // generic instantiation
+		return calleeIdent(f.X)
+	case *ast.ParenExpr:
+		return calleeIdent(f.X)
+	default:
+		return nil
+	}
+}
+
+func isPkgName(ti *store.TypeInfo, expr ast.Expr) bool {
+	id, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, isPkg := ti.Info.Uses[id].(*types.PkgName)
+	return isPkg
+}
+
+// packageVar returns the package-level variable at the root of the given
+// expression (e.g., `posts` in `posts[id].Title`), if any.
+func packageVar(ti *store.TypeInfo, expr ast.Expr) *types.Var {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			v, ok := ti.Info.Uses[e].(*types.Var)
+			if ok && ti.Pkg != nil && v.Parent() == ti.Pkg.Scope() {
+				return v
+			}
+			return nil
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// findFunc finds the top-level function with the given name in a package.
+func findFunc(pkg *store.Package, name string) (callTarget, bool) {
+	found := false
+	target := callTarget{}
+	forEachFunc(pkg, func(_ *ast.File, fn *ast.FuncDecl) {
+		if !found && fn.Recv == nil && fn.Name.Name == name {
+			target, found = declTarget(pkg, fn.Name), true
+		}
+	})
+	return target, found
+}
+
+func declTarget(pkg *store.Package, name *ast.Ident) callTarget {
+	pos := pkg.FileSet.Position(name.Pos())
+	return callTarget{Path: pos.Filename, Offset: pos.Offset, Name: name.Name}
+}
+
+func objTarget(pkg *store.Package, obj types.Object) callTarget {
+	pos := pkg.FileSet.Position(obj.Pos())
+	return callTarget{Path: pos.Filename, Offset: pos.Offset, Name: obj.Name()}
+}
+
+func funcItem(pkg *store.Package, fn *ast.FuncDecl) protocol.CallHierarchyItem {
+	name := fn.Name.Name
+	kind := protocol.SymbolKindFunction
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		name = embeddedName(fn.Recv.List[0].Type) + "." + name
+		kind = protocol.SymbolKindMethod
+	}
+
+	return protocol.CallHierarchyItem{
+		Name:           name,
+		Kind:           kind,
+		Detail:         packageDetail(pkg),
+		URI:            uri.File(pkg.FileOf(fn.Pos())),
+		Range:          pkg.Range(fn),
+		SelectionRange: pkg.Range(fn.Name),
+		Data:           declTarget(pkg, fn.Name),
+	}
+}
+
+func varItem(pkg *store.Package, v *types.Var) protocol.CallHierarchyItem {
+	rng := pkg.Range(&ast.Ident{NamePos: v.Pos(), Name: v.Name()})
+	return protocol.CallHierarchyItem{
+		Name:           v.Name(),
+		Kind:           protocol.SymbolKindVariable,
+		Detail:         packageDetail(pkg) + " (state)",
+		URI:            uri.File(pkg.FileOf(v.Pos())),
+		Range:          rng,
+		SelectionRange: rng,
+		Data:           objTarget(pkg, v),
+	}
+}
+
+// externalItem describes a target we don't have the source of. Since an
+// item needs a location, we use the (first) call site.
+func externalItem(pkg *store.Package, target callTarget, kind protocol.SymbolKind, site ast.Node) protocol.CallHierarchyItem {
+	name := target.Name
+	if kind == protocol.SymbolKindFunction {
+		name = target.Pkg[strings.LastIndex(target.Pkg, "/")+1:] + "." + name
+	}
+
+	rng := pkg.Range(site)
+	return protocol.CallHierarchyItem{
+		Name:           name,
+		Kind:           kind,
+		Detail:         target.Pkg,
+		URI:            uri.File(pkg.FileOf(site.Pos())),
+		Range:          rng,
+		SelectionRange: rng,
+		Data:           target,
+	}
+}
+
+func packageDetail(pkg *store.Package) string {
+	if pkg.ImportPath != "" {
+		return pkg.ImportPath
+	}
+	return pkg.Dir
+}
+
+// targetAt resolves the function, method or package-level variable at the
+// given offset of a package file.
+func (g *callGraph) targetAt(pkg *store.Package, path string, offset int) (callTarget, bool) {
+	file, id, sel := pkgIdentAt(pkg, path, offset)
+	if id == nil {
+		return callTarget{}, false
+	}
+
+	ti := pkg.TypeInfo()
+	switch obj := ti.ObjectOf(id).(type) {
+	case *types.Func:
+		if obj.Pkg() == ti.Pkg {
+			return objTarget(pkg, obj), true
+		}
+	case *types.Var:
+		if ti.Pkg != nil && obj.Parent() == ti.Pkg.Scope() {
+			return objTarget(pkg, obj), true
+		}
+	}
+
+	var fun ast.Expr = id
+	if sel != nil && sel.Sel == id {
+		fun = sel
+	}
+
+	target, _ := g.resolveCallee(pkg, ti, file, fun)
+	if target == nil {
+		return callTarget{}, false
+	}
+	return *target, true
+}
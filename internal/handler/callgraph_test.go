@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestCallGraph(t *testing.T) {
+	pkgs, err := store.NewDocumentStore().LoadWorkspace("../../testdata/callgraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pkgs) != 2 {
+		t.Fatalf("expected = %v, got = %v", 2, len(pkgs))
+	}
+	g := buildCallGraph(pkgs)
+
+	targets := map[string]callTarget{}
+	for target, item := range g.items {
+		targets[item.Name] = target
+	}
+
+	outgoing := []string{}
+	for _, call := range g.outgoing(targets["checkAdmin"]) {
+		outgoing = append(outgoing, call.To.Name)
+	}
+
+	sort.Strings(outgoing)
+
+	// Builtins (`panic`) aren't part of the graph.
+	expected := []string{"IsAdmin", "std.GetOrigCaller"}
+	if len(outgoing) != 2 || outgoing[0] != expected[0] || outgoing[1] != expected[1] {
+		t.Errorf("expected = %v, got = %v", expected, outgoing)
+	}
+
+	incoming := []string{}
+	for _, call := range g.incoming(targets["IsAdmin"]) {
+		incoming = append(incoming, call.From.Name)
+	}
+
+	if len(incoming) != 1 || incoming[0] != "checkAdmin" {
+		t.Errorf("expected = %v, got = %v", []string{"checkAdmin"}, incoming)
+	}
+
+	writers := []string{}
+	for _, call := range g.incoming(targets["count"]) {
+		writers = append(writers, call.From.Name)
+	}
+
+	if len(writers) != 1 || writers[0] != "Increment" {
+		t.Errorf("expected = %v, got = %v", []string{"Increment"}, writers)
+	}
+}
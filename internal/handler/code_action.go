@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func (h *handler) handleCodeAction(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.CodeActionParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	actions := []protocol.CodeAction{}
+	if doc.Pgf == nil || doc.Pgf.File == nil {
+		return reply(ctx, actions, nil)
+	}
+
+	if wantsKind(params.Context.Only, protocol.QuickFix) {
+		for _, diag := range params.Context.Diagnostics {
+			actions = append(actions, fixActions(doc, diag)...)
+		}
+	}
+
+	if wantsKind(params.Context.Only, protocol.RefactorExtract) {
+		actions = append(actions, extractActions(doc, params.Range)...)
+	}
+
+	if wantsKind(params.Context.Only, protocol.RefactorRewrite) {
+		actions = append(actions, h.rewriteActions(doc, params.Range.Start)...)
+	}
+
+	if wantsKind(params.Context.Only, codeActionKindAddTest) {
+		actions = append(actions, h.addTestAction(doc, params.Range.Start)...)
+	}
+
+	if wantsKind(params.Context.Only, protocol.SourceOrganizeImports) {
+		if edits := organizeImports(doc); len(edits) > 0 {
+			actions = append(actions, protocol.CodeAction{
+				Title: "Organize imports",
+				Kind:  protocol.SourceOrganizeImports,
+				Edit:  docEdit(doc, edits),
+			})
+		}
+	}
+
+	return reply(ctx, actions, nil)
+}
+
+// wantsKind reports whether the client asked for actions of the given kind.
+// An empty list means all kinds.
+func wantsKind(only []protocol.CodeActionKind, kind protocol.CodeActionKind) bool {
+	if len(only) == 0 {
+		return true
+	}
+
+	for _, k := range only {
+		// Kinds are hierarchical: `source` includes `source.organizeImports`.
+		if k == kind || strings.HasPrefix(string(kind), string(k)+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// docEdit wraps the given edits to a single document.
+func docEdit(doc *store.Document, edits []protocol.TextEdit) *protocol.WorkspaceEdit {
+	return &protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			doc.URI: edits,
+		},
+	}
+}
@@ -20,8 +20,9 @@ var (
 )
 
 type testFn struct {
-	Name string
-	Rng  protocol.Range
+	Name     string
+	Rng      protocol.Range
+	Subtests []testFn
 }
 
 type testFns struct {
@@ -47,6 +48,10 @@ func (h *handler) handleCodeLens(ctx context.Context, reply jsonrpc2.Replier, re
 		return noDocFound(ctx, reply, params.TextDocument.URI)
 	}
 
+	if isFiletest(doc.Path) {
+		return reply(ctx, filetestLenses(doc.Path), nil)
+	}
+
 	if !strings.HasSuffix(doc.Path, "_test.gno") {
 		return reply(ctx, items, nil)
 	}
@@ -65,76 +70,67 @@ func (h *handler) handleCodeLens(ctx context.Context, reply jsonrpc2.Replier, re
 		len(tAndB.Benchmarks),
 	)
 
-	items = append(items, addTestCmds(h.binManager.GnoBin(), doc.Path, tAndB)...)
-	items = append(items, addBenchCmds(h.binManager.GnoBin(), doc.Path, tAndB)...)
+	items = append(items, addTestCmds(doc.Path, tAndB)...)
+	items = append(items, addBenchCmds(doc.Path, tAndB)...)
 
 	return reply(ctx, items, err)
 }
 
-func addTestCmds(gnoBin, path string, tAndB testFns) []protocol.CodeLens {
-	cmds := []protocol.CodeLens{}
-	if len(tAndB.Tests) == 0 {
-		return cmds
-	}
-
-	cmds = append(cmds, newHeaderCmd(
-		"run package tests",
-		"gnols.test",
-		[]interface{}{gnoBin, path, ""},
-	))
-
-	inFile := []string{}
-	for _, fn := range tAndB.Tests {
-		inFile = append(inFile, fn.Name)
-		cmds = append(cmds, protocol.CodeLens{
-			Range: fn.Rng,
-			Command: &protocol.Command{
-				Title:     "run test",
-				Command:   "gnols.test",
-				Arguments: []interface{}{gnoBin, path, fn.Name},
-			},
-		})
-	}
-
-	cmds = append(cmds, newHeaderCmd(
-		"run file tests",
-		"gnols.test",
-		[]interface{}{gnoBin, path, strings.Join(inFile, "|")},
-	))
+func addTestCmds(path string, tAndB testFns) []protocol.CodeLens {
+	return addRunCmds(path, "gnols.test", "test", tAndB.Tests)
+}
 
-	return cmds
+func addBenchCmds(path string, tAndB testFns) []protocol.CodeLens {
+	return addRunCmds(path, "gnols.bench", "benchmark", tAndB.Benchmarks)
 }
 
-func addBenchCmds(gnoBin, path string, tAndB testFns) []protocol.CodeLens {
+// addRunCmds returns the lenses that run the package's, the file's and each
+// of the given functions.
+func addRunCmds(path, cmd, kind string, fns []testFn) []protocol.CodeLens {
 	cmds := []protocol.CodeLens{}
-	if len(tAndB.Benchmarks) == 0 {
+	if len(fns) == 0 {
 		return cmds
 	}
 
-	cmds = append(cmds, newHeaderCmd(
-		"run package benchmarks",
-		"gnols.bench",
-		[]interface{}{gnoBin, path, ""},
-	))
+	if args, err := newCommandArgs(path); err == nil {
+		cmds = append(cmds, newHeaderCmd("run package "+kind+"s", cmd, args))
+	}
 
 	inFile := []string{}
-	for _, fn := range tAndB.Benchmarks {
+	for _, fn := range fns {
+		args, err := newCommandArgs(path, fn.Name)
+		if err != nil {
+			slog.Warn("code_lens", "err", err)
+			continue
+		}
+
 		inFile = append(inFile, fn.Name)
 		cmds = append(cmds, protocol.CodeLens{
 			Range: fn.Rng,
 			Command: &protocol.Command{
-				Title:     "run benchmark",
-				Command:   "gnols.bench",
-				Arguments: []interface{}{gnoBin, path, fn.Name},
+				Title:     "run " + kind,
+				Command:   cmd,
+				Arguments: []interface{}{args},
 			},
 		})
+
+		for _, sub := range fn.Subtests {
+			if subArgs, subErr := newCommandArgs(path, sub.Name); subErr == nil {
+				cmds = append(cmds, protocol.CodeLens{
+					Range: sub.Rng,
+					Command: &protocol.Command{
+						Title:     "run subtest",
+						Command:   cmd,
+						Arguments: []interface{}{subArgs},
+					},
+				})
+			}
+		}
 	}
 
-	cmds = append(cmds, newHeaderCmd(
-		"run file benchmarks",
-		"gnols.bench",
-		[]interface{}{gnoBin, path, strings.Join(inFile, "|")},
-	))
+	if args, err := newCommandArgs(path, inFile...); err == nil && len(inFile) > 0 {
+		cmds = append(cmds, newHeaderCmd("run file "+kind+"s", cmd, args))
+	}
 
 	return cmds
 }
@@ -152,12 +148,17 @@ func testsAndBenchmarks(doc *store.Document) testFns {
 			slog.Info("code_lens", "match", fn.Name.Name)
 			rng := doc.SpanToRange(int(fn.Pos()), int(fn.End()))
 			slog.Info("code_lens", "rng", rng)
-			out.Tests = append(out.Tests, testFn{fn.Name.Name, rng})
+
+			test := testFn{Name: fn.Name.Name, Rng: rng}
+			for _, sub := range subtests(fn) {
+				test.Subtests = append(test.Subtests, testFn{Name: sub.Name, Rng: doc.NodeRange(sub.Node)})
+			}
+			out.Tests = append(out.Tests, test)
 		}
 
 		if matchTestFunc(fn, benchmarkRe, "B") {
 			rng := doc.SpanToRange(int(fn.Pos()), int(fn.End()))
-			out.Benchmarks = append(out.Benchmarks, testFn{fn.Name.Name, rng})
+			out.Benchmarks = append(out.Benchmarks, testFn{Name: fn.Name.Name, Rng: rng})
 		}
 	}
 
@@ -165,27 +166,32 @@ func testsAndBenchmarks(doc *store.Document) testFns {
 }
 
 func matchTestFunc(fn *ast.FuncDecl, nameRe *regexp.Regexp, paramID string) bool {
-	if !nameRe.MatchString(fn.Name.Name) {
+	if fn.Recv != nil || !nameRe.MatchString(fn.Name.Name) {
 		return false
 	}
 
-	// 1 parameter
+	// 1 parameter (whatever its name)
 	fields := fn.Type.Params.List
-	if len(fields) != 1 {
+	if len(fields) != 1 || len(fields[0].Names) > 1 {
 		return false
 	}
 
 	// of type *testing.T
-	_, ok := fields[0].Type.(*ast.StarExpr)
+	star, ok := fields[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+
+	sel, ok := star.X.(*ast.SelectorExpr)
 	if !ok {
 		return false
 	}
 
-	name := fields[0].Names[0].Name
-	return name == strings.ToLower(paramID)
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "testing" && sel.Sel.Name == paramID
 }
 
-func newHeaderCmd(title, cmd string, args []interface{}) protocol.CodeLens {
+func newHeaderCmd(title, cmd string, args commandArgs) protocol.CodeLens {
 	return protocol.CodeLens{
 		Range: protocol.Range{
 			Start: protocol.Position{
@@ -200,7 +206,7 @@ func newHeaderCmd(title, cmd string, args []interface{}) protocol.CodeLens {
 		Command: &protocol.Command{
 			Title:     title,
 			Command:   cmd,
-			Arguments: args,
+			Arguments: []interface{}{args},
 		},
 	}
 }
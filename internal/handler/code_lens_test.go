@@ -1,10 +1,18 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
 	"github.com/jdkato/gnols/internal/store"
 )
 
@@ -40,3 +48,117 @@ func TestCodeLensFind(t *testing.T) {
 		t.Errorf("expected = %v, got = %v", 2, len(found.Benchmarks))
 	}
 }
+
+func TestCodeLensArgs(t *testing.T) {
+	lenses := addTestCmds("/p/a_test.gno", testFns{
+		Tests: []testFn{{Name: "TestA"}, {Name: "TestB"}},
+	})
+	if len(lenses) != 4 {
+		t.Fatalf("expected = %v, got = %v", 4, len(lenses))
+	}
+
+	expected := [][]string{nil, {"TestA"}, {"TestB"}, {"TestA", "TestB"}}
+	for i, lens := range lenses {
+		// Round-trip through JSON, as the client does.
+		data, err := json.Marshal(lens.Command.Arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var arguments []interface{}
+		if err = json.Unmarshal(data, &arguments); err != nil {
+			t.Fatal(err)
+		}
+
+		args, err := parseCommandArgs(arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if args.File != "/p/a_test.gno" || !reflect.DeepEqual(args.Names, expected[i]) {
+			t.Errorf("expected = %v, got = %v", expected[i], args)
+		}
+	}
+}
+
+func TestParseCommandArgs(t *testing.T) {
+	cases := [][]interface{}{
+		nil,
+		{"gno", "/p/a_test.gno", "TestA"},
+		{map[string]interface{}{"version": 0.0, "file": "/p/a_test.gno"}},
+		{map[string]interface{}{"version": 1.0, "file": "/usr/bin/gno"}},
+		{map[string]interface{}{"version": 1.0, "file": "/p/a_test.gno", "names": []interface{}{"A|B"}}},
+	}
+
+	for _, c := range cases {
+		if _, err := parseCommandArgs(c); !errors.Is(err, ErrBadCommandArgs) {
+			t.Errorf("expected = %v, got = %v", ErrBadCommandArgs, err)
+		}
+	}
+}
+
+func TestSubtests(t *testing.T) {
+	doc := loadTestDoc(t, "code_lens/subtests_test.gno")
+
+	found := testsAndBenchmarks(doc)
+	if len(found.Tests) != 4 {
+		t.Fatalf("expected = %v, got = %v", 4, len(found.Tests))
+	}
+
+	names := map[string][]string{}
+	for _, test := range found.Tests {
+		for _, sub := range test.Subtests {
+			names[test.Name] = append(names[test.Name], sub.Name)
+		}
+	}
+
+	expected := map[string][]string{
+		"TestAdd": {"TestAdd/zero", "TestAdd/zero/both_zero", "TestAdd/positive", "TestAdd/negative"},
+		"TestMul": {"TestMul/one", "TestMul/two"},
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected = %v, got = %v", expected, names)
+	}
+
+	// The table cases' lenses are on the cases themselves.
+	if rng := found.Tests[0].Subtests[2].Rng; rng.Start.Line != 13 {
+		t.Errorf("expected = %v, got = %v", 13, rng.Start.Line)
+	}
+
+	lenses := addTestCmds(doc.Path, found)
+	subtestLenses := 0
+	for _, lens := range lenses {
+		if lens.Command.Title == "run subtest" {
+			subtestLenses++
+		}
+	}
+	if subtestLenses != 6 {
+		t.Errorf("expected = %v, got = %v", 6, subtestLenses)
+	}
+}
+
+func TestAdvertisedCommands(t *testing.T) {
+	h := &handler{connPool: &recordingConn{}, documents: store.NewDocumentStore()}
+
+	for _, cmd := range capabilities().ExecuteCommandProvider.Commands {
+		req, err := jsonrpc2.NewCall(jsonrpc2.NewNumberID(1), protocol.MethodWorkspaceExecuteCommand, protocol.ExecuteCommandParams{
+			Command: cmd,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var replyErr error
+		reply := func(_ context.Context, _ interface{}, err error) error {
+			replyErr = err
+			return nil
+		}
+
+		if err = h.handleExecuteCommand(context.Background(), reply, req); err != nil {
+			t.Fatal(err)
+		}
+		if replyErr != nil && strings.Contains(replyErr.Error(), "unknown command") {
+			t.Errorf("%s: expected a handler, got = %v", cmd, replyErr)
+		}
+	}
+}
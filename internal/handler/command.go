@@ -3,44 +3,106 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"path/filepath"
+	"strings"
 
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/gno"
 )
 
 func (h *handler) handleExecuteCommand(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
 	var params protocol.ExecuteCommandParams
 
 	if req.Params() == nil {
-		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+		return invalidParams(ctx, reply, ErrBadCommandArgs)
 	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
 		return badJSON(ctx, reply, err)
 	}
 	slog.Info("execute_command", "command", params.Command)
 
-	switch params.Command { //nolint:gocritic
+	switch params.Command {
+	case "gnols.clearCoverage":
+		h.clearCoverage(ctx)
+		return reply(ctx, nil, nil)
+	case "gnols.test", "gnols.bench", "gnols.generateTest", "gnols.filetest", "gnols.updateFiletest":
+	default:
+		return reply(ctx, nil, fmt.Errorf("unknown command: %s", params.Command))
+	}
+
+	args, err := parseCommandArgs(params.Arguments)
+	if err != nil {
+		return invalidParams(ctx, reply, err)
+	}
+	pkg := filepath.Dir(args.File)
+	settings := h.testing
+
+	switch params.Command {
 	case "gnols.test":
-		file, ok := params.Arguments[0].(string)
-		if !ok {
-			return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+		if h.binManager == nil {
+			return reply(ctx, nil, gno.ErrNoGno)
 		}
-		pkg := filepath.Dir(file)
 
-		test, ok := params.Arguments[1].(string)
-		if !ok {
-			return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+		// Tests can take a while and their progress is reported with
+		// requests to the client, so they can't block this handler.
+		go h.runTest(context.WithoutCancel(ctx), pkg, args.Names, settings, params.WorkDoneToken)
+	case "gnols.filetest", "gnols.updateFiletest":
+		if h.binManager == nil {
+			return reply(ctx, nil, gno.ErrNoGno)
+		} else if !isFiletest(args.File) {
+			return invalidParams(ctx, reply, fmt.Errorf("%w: %s is not a filetest", ErrBadCommandArgs, args.File))
 		}
 
-		h.runTest(pkg, test)
+		update := params.Command == "gnols.updateFiletest"
+		go h.runFiletest(context.WithoutCancel(ctx), args.File, update, settings, params.WorkDoneToken)
+	case "gnols.bench":
+		results, benchErr := h.runBench(ctx, pkg, args.Names, args.Count, settings)
+		return reply(ctx, results, benchErr)
+	case "gnols.generateTest":
+		if len(args.Names) != 1 {
+			return invalidParams(ctx, reply, fmt.Errorf("%w: expected 1 function", ErrBadCommandArgs))
+		}
+
+		if err = h.generateTest(ctx, args.File, args.Names[0]); err != nil {
+			return reply(ctx, nil, err)
+		}
 	}
 
 	return reply(ctx, nil, nil)
 }
 
-func (h *handler) runTest(pkg, test string) {
-	slog.Info("execute_command", "pkg", pkg, "test", test)
-	out, _ := h.binManager.RunTest(pkg, test)
+// runBench runs the benchmarks, showing their results to the user and
+// returning them to the client.
+func (h *handler) runBench(ctx context.Context, pkg string, benchmarks []string, count int, settings testSettings) ([]gno.BenchResult, error) {
+	slog.Info("execute_command", "pkg", pkg, "benchmarks", benchmarks, "count", count)
+	if h.binManager == nil {
+		return nil, gno.ErrNoGno
+	}
+
+	out, err := h.binManager.RunBench(pkg, benchmarks, count, settings.Options)
+	h.logOutput(ctx, settings, string(out))
 	slog.Info("execute_command", "out", string(out))
+
+	results := gno.ParseBench(string(out))
+	if err != nil && len(results) == 0 {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	lines := []string{}
+	for _, r := range results {
+		lines = append(lines, r.String())
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "no benchmarks were run")
+	}
+
+	h.notify(ctx, protocol.MethodWindowShowMessage, protocol.ShowMessageParams{
+		Type:    protocol.MessageTypeInfo,
+		Message: strings.Join(lines, "\n"),
+	})
+
+	return results, nil
 }
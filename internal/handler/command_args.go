@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// commandArgsVersion is the version of the `gnols.*` command arguments.
+//
+// It's bumped whenever their shape changes, so that commands built by
+// another version of the server (or by a plugin) are rejected rather than
+// misread.
+const commandArgsVersion = 1
+
+var (
+	ErrBadCommandArgs = errors.New("invalid command arguments")
+
+	funcNameRe = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_]*$`)
+)
+
+// commandArgs is the only argument of the `gnols.test`, `gnols.bench` and
+// `gnols.generateTest` commands.
+type commandArgs struct {
+	Version int      `json:"version"`
+	File    string   `json:"file"`            // the Gno file the command applies to
+	Names   []string `json:"names,omitempty"` // tests (TestX/case for subtests), benchmarks or the function to test
+	Count   int      `json:"count,omitempty"` // how many times to run benchmarks
+}
+
+// newCommandArgs returns the (validated) arguments for a command.
+func newCommandArgs(file string, names ...string) (commandArgs, error) {
+	args := commandArgs{
+		Version: commandArgsVersion,
+		File:    file,
+		Names:   names,
+	}
+	return args, args.validate()
+}
+
+// parseCommandArgs decodes and validates the arguments of a command.
+func parseCommandArgs(arguments []interface{}) (commandArgs, error) {
+	var args commandArgs
+
+	if len(arguments) != 1 {
+		return args, fmt.Errorf("%w: expected 1 argument, got %d", ErrBadCommandArgs, len(arguments))
+	}
+
+	// The argument has already been decoded as a generic map.
+	data, err := json.Marshal(arguments[0])
+	if err != nil {
+		return args, fmt.Errorf("%w: %w", ErrBadCommandArgs, err)
+	} else if err = json.Unmarshal(data, &args); err != nil {
+		return args, fmt.Errorf("%w: %w", ErrBadCommandArgs, err)
+	}
+
+	return args, args.validate()
+}
+
+func (a commandArgs) validate() error {
+	if a.Version != commandArgsVersion {
+		return fmt.Errorf("%w: unsupported version %d (expected %d)", ErrBadCommandArgs, a.Version, commandArgsVersion)
+	} else if a.File == "" || filepath.Ext(a.File) != ".gno" {
+		return fmt.Errorf("%w: %q is not a Gno file", ErrBadCommandArgs, a.File)
+	} else if a.Count < 0 {
+		return fmt.Errorf("%w: negative count", ErrBadCommandArgs)
+	}
+
+	for _, name := range a.Names {
+		// Subtests (TestX/case) can only be run one at a time, since
+		// `-run` patterns are split on slashes.
+		parts := strings.Split(name, "/")
+		if len(parts) > 1 && len(a.Names) > 1 {
+			return fmt.Errorf("%w: subtest %q must be run alone", ErrBadCommandArgs, name)
+		} else if !funcNameRe.MatchString(parts[0]) {
+			return fmt.Errorf("%w: %q is not a function name", ErrBadCommandArgs, name)
+		}
+
+		for _, part := range parts[1:] {
+			if part == "" || strings.ContainsAny(part, "\r\n") {
+				return fmt.Errorf("%w: %q is not a subtest name", ErrBadCommandArgs, name)
+			}
+		}
+	}
+
+	return nil
+}
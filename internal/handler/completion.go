@@ -3,11 +3,19 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"log/slog"
+	"path/filepath"
 	"strings"
 
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/stdlib"
+	"github.com/jdkato/gnols/internal/store"
 )
 
 func (h *handler) handleTextDocumentCompletion(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
@@ -25,9 +33,19 @@ func (h *handler) handleTextDocumentCompletion(ctx context.Context, reply jsonrp
 	}
 	items := []protocol.CompletionItem{}
 
+	if doc.Pgf != nil {
+		if lit := compositeLitAt(doc.Pgf.File, doc.PositionToPos(params.Position)); lit != nil {
+			fields := h.fieldCompletions(doc, lit)
+			if len(fields) > 0 {
+				return reply(ctx, fields, nil)
+			}
+		}
+	}
+
 	token, err := doc.TokenAt(params.Position)
 	if err != nil {
-		return reply(ctx, protocol.Hover{}, err)
+		// There's nothing to complete, but we can still offer snippets.
+		return reply(ctx, h.snippetCompletions(doc.Path, ""), nil)
 	}
 	text := strings.TrimSuffix(strings.TrimSpace(token.Text), ".")
 	slog.Info("completion", "text", text)
@@ -35,15 +53,125 @@ func (h *handler) handleTextDocumentCompletion(ctx context.Context, reply jsonrp
 	pkg := lookupPkg(text)
 	if pkg != nil {
 		for _, s := range pkg.Symbols {
-			items = append(items, protocol.CompletionItem{
+			item := protocol.CompletionItem{
 				Label:         s.Name,
 				InsertText:    s.Name,
 				Kind:          symbolToKind(s.Kind),
 				Detail:        s.Signature,
 				Documentation: s.Doc,
-			})
+			}
+
+			if s.Kind == "func" && h.usePlaceholders && h.snippetSupport {
+				if call, isCall := callSnippet(s); isCall {
+					item.InsertText = call
+					item.InsertTextFormat = protocol.InsertTextFormatSnippet
+				}
+			}
+
+			items = append(items, item)
 		}
+	} else if !strings.Contains(text, ".") {
+		items = append(items, h.snippetCompletions(doc.Path, text)...)
 	}
 
 	return reply(ctx, items, err)
 }
+
+// fieldCompletions offers the fields of a composite literal that haven't
+// been set yet, plus a "fill struct" item that sets all of them at once.
+func (h *handler) fieldCompletions(doc *store.Document, lit *compositeLit) []protocol.CompletionItem {
+	items := []protocol.CompletionItem{}
+
+	pkg, err := h.documents.LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		slog.Warn("completion", "err", err)
+		return items
+	}
+
+	st := findStruct(pkg, lit.Type)
+	if st == nil {
+		return items
+	}
+
+	remaining := []structField{}
+	for _, f := range structFields(st) {
+		if lit.Present[f.Name] {
+			continue
+		}
+		remaining = append(remaining, f)
+
+		item := protocol.CompletionItem{
+			Label:      f.Name,
+			Kind:       protocol.CompletionItemKindField,
+			Detail:     exprString(f.Type),
+			InsertText: f.Name + ": ",
+		}
+		if h.snippetSupport {
+			item.InsertText = f.Name + ": $0"
+			item.InsertTextFormat = protocol.InsertTextFormatSnippet
+		}
+
+		items = append(items, item)
+	}
+
+	if len(remaining) > 1 {
+		fill := protocol.CompletionItem{
+			Label:      "fill struct",
+			Kind:       protocol.CompletionItemKindSnippet,
+			Detail:     fmt.Sprintf("set the remaining %d fields of %s", len(remaining), lit.Type),
+			InsertText: fillStruct(remaining, false),
+			// Sort after the individual fields.
+			SortText: "~",
+		}
+		if h.snippetSupport {
+			fill.InsertText = fillStruct(remaining, true)
+			fill.InsertTextFormat = protocol.InsertTextFormatSnippet
+		}
+		items = append(items, fill)
+	}
+
+	return items
+}
+
+// callSnippet converts a function's signature into a call snippet with a
+// placeholder for each parameter: `Sprintf(${1:format}, ${2:args})`.
+func callSnippet(s stdlib.Symbol) (string, bool) {
+	fn := parseSignature(s.Signature)
+	if fn == nil {
+		return "", false
+	}
+
+	args := []string{}
+	for _, field := range fn.Type.Params.List {
+		if len(field.Names) == 0 {
+			args = append(args, exprString(field.Type))
+			continue
+		}
+		for _, name := range field.Names {
+			args = append(args, name.Name)
+		}
+	}
+
+	for i, arg := range args {
+		args[i] = fmt.Sprintf("${%d:%s}", i+1, escapeSnippet(arg))
+	}
+
+	return fmt.Sprintf("%s(%s)", fn.Name.Name, strings.Join(args, ", ")), true
+}
+
+// parseSignature parses a function signature (as stored in the symbol index)
+// into a declaration.
+func parseSignature(sig string) *ast.FuncDecl {
+	src := fmt.Sprintf("package p\n%s", sig)
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil || len(file.Decls) != 1 {
+		return nil
+	}
+
+	fn, ok := file.Decls[0].(*ast.FuncDecl)
+	if !ok {
+		return nil
+	}
+	return fn
+}
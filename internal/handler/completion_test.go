@@ -1,6 +1,10 @@
 package handler
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/jdkato/gnols/internal/store"
+)
 
 func TestLookupPkg(t *testing.T) {
 	pkg := lookupPkg("fmt")
@@ -21,3 +25,69 @@ func TestLookupPkg(t *testing.T) {
 		t.Errorf("Expected symbols, got %v", len(pkg.Symbols))
 	}
 }
+
+func TestCallSnippet(t *testing.T) {
+	sym := lookupSymbol("ufmt", "Sprintf")
+	if sym == nil {
+		t.Fatal("Unexpected nil; ufmt.Sprintf should be found")
+	}
+
+	call, ok := callSnippet(*sym)
+	if !ok {
+		t.Fatalf("Expected a call snippet for %s", sym.Signature)
+	}
+
+	expected := "Sprintf(${1:format}, ${2:args})"
+	if call != expected {
+		t.Errorf("Expected %s, got %s", expected, call)
+	}
+}
+
+func TestCompositeLitAt(t *testing.T) {
+	cases := []struct {
+		src     string
+		typ     string
+		present []string
+	}{
+		{"p := &Post{\n\tTitle: \"hi\",\n\tTags: []string{\"a\"},\n\t", "Post", []string{"Title", "Tags"}},
+		{"p := Post{Title: \"}\", ", "Post", []string{"Title"}},
+		{"p := Post{\n\t// Body: {\n\t", "Post", nil},
+		{"p := blog.Post{", "blog.Post", nil},
+		{"p := Post{Title: ", "", nil},
+		{"s := \"{\"\n\t", "", nil},
+		{"if x {\n\t", "", nil},
+	}
+
+	for _, c := range cases {
+		src := "package p\n\nfunc f() {\n\t" + c.src
+		pgf, _ := store.NewParsedGnoFile("p.gno", src)
+		if pgf == nil {
+			t.Fatalf("Expected a partial AST for %q", c.src)
+		}
+
+		tf := pgf.FileSet.File(pgf.File.Pos())
+		lit := compositeLitAt(pgf.File, tf.Pos(len(src)))
+		if c.typ == "" {
+			if lit != nil {
+				t.Errorf("Expected nil for %q, got %v", c.src, lit)
+			}
+			continue
+		} else if lit == nil {
+			t.Errorf("Expected a composite literal for %q, got nil", c.src)
+			continue
+		}
+
+		if lit.Type != c.typ {
+			t.Errorf("Expected %s, got %s", c.typ, lit.Type)
+		}
+
+		if len(lit.Present) != len(c.present) {
+			t.Errorf("Expected %v to be present, got %v", c.present, lit.Present)
+		}
+		for _, name := range c.present {
+			if !lit.Present[name] {
+				t.Errorf("Expected %s to be present in %q, got %v", name, c.src, lit.Present)
+			}
+		}
+	}
+}
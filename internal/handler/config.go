@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"path/filepath"
+	"sort"
+	"time"
 
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
@@ -31,6 +34,113 @@ func (h *handler) handleDidChangeConfiguration(ctx context.Context, reply jsonrp
 	precompile, _ := settings["precompileOnSave"].(bool)
 	build, _ := settings["buildOnSave"].(bool)
 
+	h.usePlaceholders, _ = settings["usePlaceholders"].(bool)
+	h.organizeOnSave, _ = settings["organizeImportsOnSave"].(bool)
+
+	tests, _ := settings["test"].(map[string]interface{})
+	h.testing = readTestSettings(tests, h.rootDir)
+
+	coverage, _ := settings["coverage"].(bool)
+	if h.coverage.Swap(coverage) && !coverage {
+		h.clearCoverage(ctx)
+	}
+
+	hints, _ := settings["hints"].(map[string]interface{})
+	h.hints.ParameterNames, _ = hints["parameterNames"].(bool)
+	h.hints.AssignVariableTypes, _ = hints["assignVariableTypes"].(bool)
+	h.hints.RangeVariableTypes, _ = hints["rangeVariableTypes"].(bool)
+
 	h.binManager, err = gno.NewBinManager(gnoBin, gnokey, precompile, build)
-	return reply(ctx, nil, err)
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	// This is a notification, so the client would ignore an error in the
+	// reply: we show it instead.
+	formatter, _ := settings["formatter"].(map[string]interface{})
+	if err = h.binManager.SetFormatter(formatterSettings(formatter)); err != nil {
+		slog.Warn("configuration changed", "formatter", err)
+		h.notify(ctx, protocol.MethodWindowShowMessage, protocol.ShowMessageParams{
+			Type:    protocol.MessageTypeError,
+			Message: "Invalid formatter: " + err.Error(),
+		})
+	}
+
+	return reply(ctx, nil, nil)
+}
+
+// formatterSettings reads the `formatter` settings:
+//
+//	{"tool": "custom", "command": ["gofumpt"], "timeout": 5}
+//
+// where the timeout is in seconds.
+func formatterSettings(settings map[string]interface{}) gno.Formatter {
+	f := gno.Formatter{}
+
+	f.Tool, _ = settings["tool"].(string)
+	if seconds, ok := settings["timeout"].(float64); ok {
+		f.Timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	command, _ := settings["command"].([]interface{})
+	for _, arg := range command {
+		if s, ok := arg.(string); ok {
+			f.Command = append(f.Command, s)
+		}
+	}
+
+	return f
+}
+
+// readTestSettings reads the `test` settings:
+//
+//	{
+//	  "timeout": "5m",
+//	  "verbose": true,
+//	  "args": ["-root-dir", "/path/to/gno"],
+//	  "env": {"GNOROOT": "/path/to/gno"},
+//	  "dir": "."
+//	}
+//
+// where the timeout is a duration (or a number of seconds) and a relative
+// directory is relative to the workspace root.
+func readTestSettings(settings map[string]interface{}, root string) testSettings {
+	s := testSettings{}
+
+	switch timeout := settings["timeout"].(type) {
+	case string:
+		if d, err := time.ParseDuration(timeout); err == nil {
+			s.Options.Timeout = d
+		} else {
+			slog.Warn("configuration changed", "bad timeout", timeout)
+		}
+	case float64:
+		s.Options.Timeout = time.Duration(timeout * float64(time.Second))
+	}
+
+	s.Verbose, _ = settings["verbose"].(bool)
+
+	args, _ := settings["args"].([]interface{})
+	for _, arg := range args {
+		if a, ok := arg.(string); ok {
+			s.Options.Args = append(s.Options.Args, a)
+		}
+	}
+
+	env, _ := settings["env"].(map[string]interface{})
+	for key, value := range env {
+		if v, ok := value.(string); ok {
+			s.Options.Env = append(s.Options.Env, key+"="+v)
+		}
+	}
+	sort.Strings(s.Options.Env)
+
+	if dir, _ := settings["dir"].(string); dir != "" {
+		if !filepath.IsAbs(dir) && root != "" {
+			dir = filepath.Join(root, dir)
+		}
+		s.Options.Dir = dir
+	}
+
+	return s
 }
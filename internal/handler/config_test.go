@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/gno"
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// recordingConn records the notifications sent to the client.
+type recordingConn struct {
+	jsonrpc2.Conn
+
+	mu    sync.Mutex
+	notes map[string][]json.RawMessage
+}
+
+func (c *recordingConn) Notify(_ context.Context, method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.notes == nil {
+		c.notes = map[string][]json.RawMessage{}
+	}
+	c.notes[method] = append(c.notes[method], data)
+	return nil
+}
+
+func (c *recordingConn) sent(method string) []json.RawMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.notes[method]
+}
+
+func TestReadTestSettings(t *testing.T) {
+	root := filepath.FromSlash("/work")
+
+	s := readTestSettings(map[string]interface{}{
+		"timeout": "2m",
+		"verbose": true,
+		"args":    []interface{}{"-root-dir", "/gno"},
+		"env":     map[string]interface{}{"B": "2", "A": "1"},
+		"dir":     "pkg",
+	}, root)
+
+	if s.Options.Timeout != 2*time.Minute {
+		t.Errorf("expected = %v, got = %v", 2*time.Minute, s.Options.Timeout)
+	}
+	if !s.Verbose {
+		t.Errorf("expected = %v, got = %v", true, s.Verbose)
+	}
+	if len(s.Options.Args) != 2 || s.Options.Args[0] != "-root-dir" {
+		t.Errorf("expected = %v, got = %v", []string{"-root-dir", "/gno"}, s.Options.Args)
+	}
+	if len(s.Options.Env) != 2 || s.Options.Env[0] != "A=1" {
+		t.Errorf("expected = %v, got = %v", []string{"A=1", "B=2"}, s.Options.Env)
+	}
+	if dir := filepath.Join(root, "pkg"); s.Options.Dir != dir {
+		t.Errorf("expected = %v, got = %v", dir, s.Options.Dir)
+	}
+
+	s = readTestSettings(map[string]interface{}{"timeout": float64(90)}, root)
+	if s.Options.Timeout != 90*time.Second {
+		t.Errorf("expected = %v, got = %v", 90*time.Second, s.Options.Timeout)
+	}
+}
+
+func TestConfigFormatterError(t *testing.T) {
+	conn := &recordingConn{}
+	h := &handler{connPool: conn, documents: store.NewDocumentStore()}
+
+	req, err := jsonrpc2.NewNotification(protocol.MethodWorkspaceDidChangeConfiguration, protocol.DidChangeConfigurationParams{
+		Settings: map[string]interface{}{
+			"gno":       "echo",
+			"formatter": map[string]interface{}{"tool": "custom"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var replyErr error
+	reply := func(_ context.Context, _ interface{}, err error) error {
+		replyErr = err
+		return nil
+	}
+
+	if err = h.handleDidChangeConfiguration(context.Background(), reply, req); err != nil {
+		t.Fatal(err)
+	} else if replyErr != nil {
+		t.Errorf("expected = %v, got = %v", nil, replyErr)
+	}
+
+	msgs := conn.sent(protocol.MethodWindowShowMessage)
+	if len(msgs) != 1 || !strings.Contains(string(msgs[0]), gno.ErrNoFormatCommand.Error()) {
+		t.Errorf("expected = %v, got = %s", "an invalid formatter message", msgs)
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+
+	"github.com/jdkato/gnols/internal/gno"
+)
+
+var errNoCoverProfile = errors.New("no coverage profile was written")
+
+// methodCoverage is the notification that carries the coverage of the last
+// test run, so that plugins can decorate covered and uncovered lines. An
+// empty list of files clears the overlay.
+const methodCoverage = "gnols/coverage"
+
+type coverageParams struct {
+	Package string         `json:"package,omitempty"`
+	Percent float64        `json:"percent"` // of statements
+	Files   []fileCoverage `json:"files"`
+}
+
+type fileCoverage struct {
+	URI       protocol.DocumentURI `json:"uri"`
+	Covered   []protocol.Range     `json:"covered"`
+	Uncovered []protocol.Range     `json:"uncovered"`
+}
+
+// coverProfile returns a path for a test run's coverage profile, if
+// coverage is enabled.
+func (h *handler) coverProfile() string {
+	if !h.coverage.Load() {
+		return ""
+	}
+
+	f, err := os.CreateTemp("", "gnols-*.cover")
+	if err != nil {
+		slog.Warn("coverage", "error", err)
+		return ""
+	}
+	f.Close()
+
+	return f.Name()
+}
+
+// publishCoverage shows the coverage of a test run: as a `gnols/coverage`
+// notification and as hints on the uncovered blocks.
+func (h *handler) publishCoverage(ctx context.Context, pkg, profile string) {
+	defer os.Remove(profile)
+
+	// The profile is created before the run, so an empty one means that the
+	// tests didn't write it (e.g., because they didn't build).
+	data, err := os.ReadFile(profile)
+	if err == nil && len(data) == 0 {
+		err = errNoCoverProfile
+	}
+
+	if err != nil {
+		slog.Warn("coverage", "error", err)
+		h.notify(ctx, protocol.MethodWindowShowMessage, protocol.ShowMessageParams{
+			Type:    protocol.MessageTypeWarning,
+			Message: "Unable to read the coverage of " + pkg + ": " + err.Error(),
+		})
+		return
+	}
+
+	params, diags := h.coverageResults(pkg, gno.ParseCoverProfile(string(data)))
+	slog.Info("coverage", "pkg", pkg, "files", len(params.Files), "percent", params.Percent)
+
+	h.notify(ctx, methodCoverage, params)
+	h.publishDiagnostics(ctx, h.diagnostics.setCoverage(diags))
+}
+
+// clearCoverage removes the coverage overlay.
+func (h *handler) clearCoverage(ctx context.Context) {
+	h.notify(ctx, methodCoverage, coverageParams{Files: []fileCoverage{}})
+	h.publishDiagnostics(ctx, h.diagnostics.setCoverage(nil))
+}
+
+func (h *handler) publishDiagnostics(ctx context.Context, diags map[string][]protocol.Diagnostic) {
+	for path, d := range diags {
+		h.notify(ctx, protocol.MethodTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+			URI:         uri.File(path),
+			Diagnostics: d,
+		})
+	}
+}
+
+// coverageResults converts the blocks of a coverage profile into ranges
+// and hint diagnostics, by file. Blocks of files we can't load still count
+// towards the percentage.
+func (h *handler) coverageResults(pkg string, blocks []gno.CoverBlock) (coverageParams, map[string][]protocol.Diagnostic) {
+	params := coverageParams{Package: pkg, Files: []fileCoverage{}}
+	diags := map[string][]protocol.Diagnostic{}
+	files := map[string]*fileCoverage{}
+
+	total, covered := 0, 0
+	for _, b := range blocks {
+		total += b.NumStmt
+		if b.Count > 0 {
+			covered += b.NumStmt
+		}
+
+		path := filepath.Join(pkg, filepath.Base(b.File))
+
+		doc, err := h.documents.LoadDocument(path)
+		if err != nil {
+			continue
+		}
+
+		fc, ok := files[path]
+		if !ok {
+			fc = &fileCoverage{
+				URI:       uri.File(path),
+				Covered:   []protocol.Range{},
+				Uncovered: []protocol.Range{},
+			}
+			files[path] = fc
+		}
+
+		rng := protocol.Range{
+			Start: doc.LineColToPosition(b.StartLine, b.StartCol),
+			End:   doc.LineColToPosition(b.EndLine, b.EndCol),
+		}
+
+		if b.Count > 0 {
+			fc.Covered = append(fc.Covered, rng)
+			continue
+		}
+
+		fc.Uncovered = append(fc.Uncovered, rng)
+		diags[path] = append(diags[path], protocol.Diagnostic{
+			Range:    rng,
+			Severity: protocol.DiagnosticSeverityHint,
+			Source:   "gnols",
+			Code:     "coverage",
+			Message:  "not covered by tests",
+		})
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		params.Files = append(params.Files, *files[path])
+	}
+
+	if total > 0 {
+		params.Percent = 100 * float64(covered) / float64(total)
+	}
+
+	return params, diags
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/gno"
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestCoverageResults(t *testing.T) {
+	pkg, err := filepath.Abs("../../testdata/discover/p/math")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &handler{documents: store.NewDocumentStore()}
+	params, diags := h.coverageResults(pkg, []gno.CoverBlock{
+		{File: "gno.land/p/demo/math/math.gno", StartLine: 3, StartCol: 24, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+		{File: "gno.land/p/demo/math/missing.gno", StartLine: 1, StartCol: 1, EndLine: 2, EndCol: 1, NumStmt: 3, Count: 1},
+	})
+
+	// The missing file's statements still count.
+	if len(params.Files) != 1 || params.Percent != 75 {
+		t.Fatalf("expected = %v, got = %v", "1 file, 75%", params)
+	}
+
+	expected := protocol.Range{
+		Start: protocol.Position{Line: 2, Character: 23},
+		End:   protocol.Position{Line: 4, Character: 1},
+	}
+	if uncovered := params.Files[0].Uncovered; len(uncovered) != 1 || uncovered[0] != expected {
+		t.Errorf("expected = %v, got = %v", expected, uncovered)
+	}
+
+	hints := diags[filepath.Join(pkg, "math.gno")]
+	if len(hints) != 1 || hints[0].Severity != protocol.DiagnosticSeverityHint {
+		t.Errorf("expected = %v, got = %v", "1 hint", hints)
+	}
+}
+
+func TestPublishCoverageError(t *testing.T) {
+	conn := &recordingConn{}
+	h := &handler{connPool: conn, documents: store.NewDocumentStore()}
+
+	profile := filepath.Join(t.TempDir(), "empty.cover")
+	if err := os.WriteFile(profile, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h.publishCoverage(context.Background(), "/p", profile)
+
+	msgs := conn.sent(protocol.MethodWindowShowMessage)
+	if len(msgs) != 1 || !strings.Contains(string(msgs[0]), errNoCoverProfile.Error()) {
+		t.Errorf("expected = %v, got = %s", errNoCoverProfile, msgs)
+	}
+	if sent := conn.sent(methodCoverage); len(sent) != 0 {
+		t.Errorf("expected = %v, got = %s", 0, sent)
+	}
+}
+
+func TestRunTestNoCoverage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as `gno`")
+	}
+	dir := t.TempDir()
+
+	// A `gno` that predates `-coverprofile`.
+	bin := filepath.Join(dir, "gno")
+	script := "#!/bin/sh\necho 'flag provided but not defined: -coverprofile'\nexit 2\n"
+	if err := os.WriteFile(bin, []byte(script), 0o700); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+
+	mgr, err := gno.NewBinManager(bin, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn := &recordingConn{}
+	h := &handler{connPool: conn, documents: store.NewDocumentStore(), binManager: mgr}
+	h.coverage.Store(true)
+
+	h.runTest(context.Background(), dir, nil, testSettings{}, nil)
+
+	msgs := conn.sent(protocol.MethodWindowShowMessage)
+	if len(msgs) != 1 || !strings.Contains(string(msgs[0]), gno.ErrNoCoverage.Error()) {
+		t.Errorf("expected = %v, got = %s", gno.ErrNoCoverage, msgs)
+	}
+	if sent := conn.sent(methodCoverage); len(sent) != 0 {
+		t.Errorf("expected = %v, got = %s", 0, sent)
+	}
+}
@@ -20,7 +20,7 @@ func (h *handler) notifcationFromGno(ctx context.Context, conn jsonrpc2.Conn, do
 		protocol.MethodTextDocumentPublishDiagnostics,
 		&protocol.PublishDiagnosticsParams{
 			URI:         doc.URI,
-			Diagnostics: diagnostics,
+			Diagnostics: h.diagnostics.setBuild(doc.Path, diagnostics),
 		},
 	)
 }
@@ -40,13 +40,19 @@ func (h *handler) getDiagnostics(doc *store.Document) ([]protocol.Diagnostic, er
 	}
 
 	for _, entry := range computed {
-		diagnostics = append(diagnostics, protocol.Diagnostic{
+		diag := protocol.Diagnostic{
 			Range:    *posToRange(entry.Line, entry.Span),
 			Severity: protocol.DiagnosticSeverityError,
 			Source:   "gnols",
 			Message:  entry.Msg,
 			Code:     entry.Tool,
-		})
+		}
+
+		if fixes := diagnosticFixes(doc, diag); len(fixes) > 0 {
+			diag.Data = diagnosticData{Fixes: fixes}
+		}
+
+		diagnostics = append(diagnostics, diag)
 	}
 
 	slog.Info("diagnostics", "parsed", computed, "count", len(diagnostics))
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"go.lsp.dev/protocol"
+)
+
+// A hunk replaces the old lines [OldStart, OldEnd) with the new lines
+// [NewStart, NewEnd).
+type hunk struct {
+	OldStart, OldEnd int
+	NewStart, NewEnd int
+}
+
+// diffEdits returns the (line-based) edits that turn the old text into the
+// new one, so that the client can keep its cursors, folds and undo history
+// for the unchanged lines.
+func diffEdits(oldText, newText string) []protocol.TextEdit {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	edits := []protocol.TextEdit{}
+	for _, h := range diffLines(oldLines, newLines) {
+		edits = append(edits, protocol.TextEdit{
+			Range: protocol.Range{
+				Start: linePosition(oldLines, h.OldStart),
+				End:   linePosition(oldLines, h.OldEnd),
+			},
+			NewText: strings.Join(newLines[h.NewStart:h.NewEnd], ""),
+		})
+	}
+
+	return edits
+}
+
+// applyEdits returns the text with the (non-overlapping) edits applied.
+func applyEdits(text string, edits []protocol.TextEdit) string {
+	lines := strings.SplitAfter(text, "\n")
+	offset := func(pos protocol.Position) int {
+		if int(pos.Line) >= len(lines) {
+			return len(text)
+		}
+
+		n := 0
+		for _, line := range lines[:pos.Line] {
+			n += len(line)
+		}
+
+		units := 0
+		for i, r := range lines[pos.Line] {
+			if units >= int(pos.Character) {
+				return n + i
+			}
+			units += len(utf16.Encode([]rune{r}))
+		}
+		return n + len(lines[pos.Line])
+	}
+
+	sorted := append([]protocol.TextEdit{}, edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return offset(sorted[i].Range.Start) < offset(sorted[j].Range.Start)
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, edit := range sorted {
+		start := offset(edit.Range.Start)
+		if start < last {
+			continue // overlapping
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(edit.NewText)
+		last = offset(edit.Range.End)
+	}
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+// splitLines splits the text into lines, keeping their line endings.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// linePosition returns the position of the start of the given line. The
+// position after the last line is the end of the text (which might not end
+// with a newline).
+func linePosition(lines []string, line int) protocol.Position {
+	if line < len(lines) || line == 0 {
+		return protocol.Position{Line: uint32(line)}
+	}
+
+	last := lines[line-1]
+	if strings.HasSuffix(last, "\n") {
+		return protocol.Position{Line: uint32(line)}
+	}
+
+	return protocol.Position{
+		Line:      uint32(line - 1),
+		Character: uint32(len(utf16.Encode([]rune(last)))),
+	}
+}
+
+// diffLines computes the hunks between two lists of lines using the
+// linear-space variant of Myers' algorithm, which splits the problem at the
+// "middle snake" of an optimal edit script rather than recording every step
+// (so that it uses O(N+M) memory instead of O(D*(N+M))).
+func diffLines(a, b []string) []hunk {
+	size := 2*((len(a)+len(b)+1)/2) + 3
+
+	d := &differ{a: a, b: b, fwd: make([]int, size), bwd: make([]int, size)}
+	d.compare(0, len(a), 0, len(b))
+
+	return d.hunks
+}
+
+// A differ holds the state of a single diff: the furthest reaching paths of
+// the forward and backward searches are shared by every call to
+// `middleSnake`, since they don't overlap.
+type differ struct {
+	a, b     []string
+	fwd, bwd []int
+	hunks    []hunk
+}
+
+// compare diffs a[aLo:aHi] with b[bLo:bHi], adding the changed regions to
+// the hunks in order.
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	if aLo == aHi || bLo == bHi {
+		if aLo < aHi || bLo < bHi {
+			d.add(aLo, aHi, bLo, bHi)
+		}
+		return
+	}
+
+	x, y, u, v, cost := d.middleSnake(aLo, aHi, bLo, bHi)
+	if cost < 2 {
+		// Without common lines at either end, both sides can't be
+		// non-empty with a single change; this is just a safeguard.
+		d.add(aLo, aHi, bLo, bHi)
+		return
+	}
+
+	d.compare(aLo, x, bLo, y)
+	d.compare(u, aHi, v, bHi)
+}
+
+// middleSnake finds the middle snake of an optimal path from (aLo, bLo) to
+// (aHi, bHi): the run of equal lines [x, u) and [y, v) in the middle of it.
+// It also returns the length of the optimal edit script.
+//
+// We search forward from the start and backward from the end (in reversed
+// coordinates) until the paths on a diagonal overlap.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v, cost int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	d.fwd[offset+1], d.bwd[offset+1] = 0, 0
+
+	for step := 0; step <= maxD; step++ {
+		for k := -step; k <= step; k += 2 {
+			x0 := d.fwd[offset+k-1] + 1 // right: deletion
+			if k == -step || (k != step && d.fwd[offset+k-1] < d.fwd[offset+k+1]) {
+				x0 = d.fwd[offset+k+1] // down: insertion
+			}
+
+			fx, fy := x0, x0-k
+			for fx < n && fy < m && d.a[aLo+fx] == d.b[bLo+fy] {
+				fx++
+				fy++
+			}
+			d.fwd[offset+k] = fx
+
+			// The backward path on this diagonal is `delta - k` (in reversed
+			// coordinates), from the previous step.
+			if rk := delta - k; odd && rk >= -(step-1) && rk <= step-1 && fx+d.bwd[offset+rk] >= n {
+				return aLo + x0, bLo + x0 - k, aLo + fx, bLo + fy, 2*step - 1
+			}
+		}
+
+		for rk := -step; rk <= step; rk += 2 {
+			rx0 := d.bwd[offset+rk-1] + 1
+			if rk == -step || (rk != step && d.bwd[offset+rk-1] < d.bwd[offset+rk+1]) {
+				rx0 = d.bwd[offset+rk+1]
+			}
+
+			rx, ry := rx0, rx0-rk
+			for rx < n && ry < m && d.a[aHi-1-rx] == d.b[bHi-1-ry] {
+				rx++
+				ry++
+			}
+			d.bwd[offset+rk] = rx
+
+			if k := delta - rk; !odd && k >= -step && k <= step && d.fwd[offset+k]+rx >= n {
+				return aHi - rx, bHi - ry, aHi - rx0, bHi - (rx0 - rk), 2 * step
+			}
+		}
+	}
+
+	// Unreachable: the paths always meet within `maxD` steps.
+	return aLo, bLo, aLo, bLo, n + m
+}
+
+// add records a changed region, merging it with the previous hunk if
+// they're adjacent.
+func (d *differ) add(aLo, aHi, bLo, bHi int) {
+	if n := len(d.hunks); n > 0 {
+		last := &d.hunks[n-1]
+		if last.OldEnd == aLo && last.NewEnd == bLo {
+			last.OldEnd, last.NewEnd = aHi, bHi
+			return
+		}
+	}
+	d.hunks = append(d.hunks, hunk{OldStart: aLo, OldEnd: aHi, NewStart: bLo, NewEnd: bHi})
+}
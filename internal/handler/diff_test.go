@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDiffEdits(t *testing.T) {
+	cases := []struct {
+		before string
+		after  string
+		edits  int
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", 0},
+		{"a\nb\nc\n", "a\nB\nc\n", 1},
+		{"a\nb\nc\nd\ne\n", "x\nb\nc\nd\ny\n", 2},
+		{"a\nb", "a\nb\n", 1},
+		{"a\n\n\nb\n", "a\n\nb\n", 1},
+		{"", "package a\n", 1},
+	}
+
+	for _, c := range cases {
+		edits := diffEdits(c.before, c.after)
+		if len(edits) != c.edits {
+			t.Errorf("expected = %v, got = %v", c.edits, len(edits))
+		}
+
+		if got := applyEdits(c.before, edits); got != c.after {
+			t.Errorf("expected = %q, got = %q", c.after, got)
+		}
+	}
+}
+
+func TestEditsWithin(t *testing.T) {
+	edits := diffEdits("a\nb\nc\nd\ne\n", "x\nb\nc\nd\ny\n")
+
+	within := editsWithin(edits, 3, 4)
+	if len(within) != 1 || within[0].NewText != "y\n" {
+		t.Errorf("expected = %v, got = %v", "y", within)
+	}
+
+	within = editsWithin(edits, 1, 3)
+	if len(within) != 0 {
+		t.Errorf("expected = %v, got = %v", 0, len(within))
+	}
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, r.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+
+		changed := 0
+		for _, h := range diffLines(a, b) {
+			changed += h.OldEnd - h.OldStart + h.NewEnd - h.NewStart
+		}
+
+		// An optimal diff keeps every line of a longest common subsequence.
+		if expected := len(a) + len(b) - 2*lcs(a, b); changed != expected {
+			t.Errorf("%v -> %v: expected = %v, got = %v", a, b, expected, changed)
+		}
+
+		before, after := strings.Join(a, "\n"), strings.Join(b, "\n")
+		if got := applyEdits(before, diffEdits(before, after)); got != after {
+			t.Errorf("expected = %q, got = %q", after, got)
+		}
+	}
+}
+
+func TestDiffEditsLarge(t *testing.T) {
+	var before, after strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&before, "old line %d\n", i)
+		fmt.Fprintf(&after, "new line %d\n", i)
+	}
+
+	var start, end runtime.MemStats
+	runtime.ReadMemStats(&start)
+	edits := diffEdits(before.String(), after.String())
+	runtime.ReadMemStats(&end)
+
+	if len(edits) != 1 {
+		t.Errorf("expected = %v, got = %v", 1, len(edits))
+	}
+	if got := applyEdits(before.String(), edits); got != after.String() {
+		t.Errorf("expected = %v, got = %v", "the rewritten text", "a different one")
+	}
+
+	// Recording every step of the search would take gigabytes here.
+	if allocated := end.TotalAlloc - start.TotalAlloc; allocated > 32<<20 {
+		t.Errorf("expected = %v, got = %v", "< 32 MiB", allocated)
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		curr := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				curr[j+1] = prev[j] + 1
+			} else {
+				curr[j+1] = max(prev[j+1], curr[j])
+			}
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func (h *handler) handleDocumentHighlight(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.DocumentHighlightParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	return reply(ctx, documentHighlights(doc, params.Position), nil)
+}
+
+// documentHighlights returns every occurrence of the identifier at the given
+// position, resolved by scope: a shadowed variable with the same name isn't
+// highlighted.
+func documentHighlights(doc *store.Document, pos protocol.Position) []protocol.DocumentHighlight {
+	highlights := []protocol.DocumentHighlight{}
+
+	id, sel := identAt(doc, pos)
+	if id == nil {
+		return highlights
+	}
+	ti := doc.TypeInfo()
+
+	var matches func(*ast.Ident) bool
+	if obj := ti.ObjectOf(id); obj != nil {
+		matches = func(other *ast.Ident) bool {
+			return ti.ObjectOf(other) == obj
+		}
+	} else if sel != nil && sel.Sel == id {
+		// Members of unresolved (i.e., Gno-only) packages, like
+		// `std.GetOrigCaller`, are matched by name.
+		x, isIdent := sel.X.(*ast.Ident)
+		if !isIdent {
+			return highlights
+		}
+		members := map[*ast.Ident]bool{}
+		ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+			if other, isSel := n.(*ast.SelectorExpr); isSel {
+				if otherX, isX := other.X.(*ast.Ident); isX && otherX.Name == x.Name && other.Sel.Name == id.Name {
+					members[other.Sel] = true
+				}
+			}
+			return true
+		})
+		matches = func(other *ast.Ident) bool {
+			return members[other]
+		}
+	} else {
+		return highlights
+	}
+
+	writes := writtenIdents(doc.Pgf.File)
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		other, isIdent := n.(*ast.Ident)
+		if !isIdent || !matches(other) {
+			return true
+		}
+
+		kind := protocol.DocumentHighlightKindRead
+		if writes[other] || ti.Info.Defs[other] != nil {
+			kind = protocol.DocumentHighlightKindWrite
+		}
+
+		highlights = append(highlights, protocol.DocumentHighlight{
+			Range: doc.NodeRange(other),
+			Kind:  kind,
+		})
+		return true
+	})
+
+	return highlights
+}
+
+// writtenIdents returns the identifiers that are assigned to in the file:
+// the targets of assignments, `++`/`--` statements and range clauses.
+func writtenIdents(file *ast.File) map[*ast.Ident]bool {
+	writes := map[*ast.Ident]bool{}
+
+	mark := func(expr ast.Expr) {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			writes[e] = true
+		case *ast.SelectorExpr:
+			writes[e.Sel] = true
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range node.Lhs {
+				mark(lhs)
+			}
+		case *ast.IncDecStmt:
+			mark(node.X)
+		case *ast.RangeStmt:
+			if node.Key != nil {
+				mark(node.Key)
+			}
+			if node.Value != nil {
+				mark(node.Value)
+			}
+		}
+		return true
+	})
+
+	return writes
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestDocumentHighlights(t *testing.T) {
+	doc := loadTestDoc(t, "semantic/realm.gno")
+
+	// The `Value` field, as written to by `c.Value += n`.
+	highlights := documentHighlights(doc, protocol.Position{Line: 21, Character: 4})
+
+	kinds := map[uint32]protocol.DocumentHighlightKind{}
+	for _, h := range highlights {
+		kinds[h.Range.Start.Line] = h.Kind
+	}
+
+	expected := map[uint32]protocol.DocumentHighlightKind{
+		11: protocol.DocumentHighlightKindWrite, // declaration
+		21: protocol.DocumentHighlightKindWrite,
+		22: protocol.DocumentHighlightKindRead,
+		26: protocol.DocumentHighlightKindRead,
+	}
+
+	if len(kinds) != len(expected) {
+		t.Fatalf("expected = %v, got = %v", expected, kinds)
+	}
+
+	for line, kind := range expected {
+		if kinds[line] != kind {
+			t.Errorf("line %d: expected = %v, got = %v", line, kind, kinds[line])
+		}
+	}
+
+	// `std.GetOrigCaller` can't be resolved, so it's matched by name.
+	highlights = documentHighlights(doc, protocol.Position{Line: 17, Character: 16})
+	if len(highlights) != 1 {
+		t.Errorf("expected = %v, got = %v", 1, len(highlights))
+	}
+}
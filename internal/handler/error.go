@@ -23,3 +23,8 @@ func badJSON(ctx context.Context, reply jsonrpc2.Replier, err error) error {
 	slog.Warn("Could not parse JSON", "err", err)
 	return reply(ctx, nil, err)
 }
+
+func invalidParams(ctx context.Context, reply jsonrpc2.Replier, err error) error {
+	slog.Warn("Invalid params", "err", err)
+	return reply(ctx, nil, &jsonrpc2.Error{Code: jsonrpc2.InvalidParams, Message: err.Error()})
+}
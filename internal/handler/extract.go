@@ -0,0 +1,531 @@
+package handler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// extractActions returns the `refactor.extract` actions for the selected
+// range: extracting an expression into a variable, or statements into a
+// function.
+func extractActions(doc *store.Document, rng protocol.Range) []protocol.CodeAction {
+	actions := []protocol.CodeAction{}
+
+	start, end := selectionPos(doc, rng)
+	if !start.IsValid() || start >= end {
+		return actions
+	}
+
+	ti := doc.TypeInfo()
+	if ti == nil || ti.Pkg == nil {
+		return actions
+	}
+
+	if edits := extractVariable(doc, ti, start, end); len(edits) > 0 {
+		actions = append(actions, protocol.CodeAction{
+			Title: "Extract variable",
+			Kind:  protocol.RefactorExtract,
+			Edit:  docEdit(doc, edits),
+		})
+	}
+
+	if edits := extractFunction(doc, ti, start, end); len(edits) > 0 {
+		actions = append(actions, protocol.CodeAction{
+			Title: "Extract function",
+			Kind:  protocol.RefactorExtract,
+			Edit:  docEdit(doc, edits),
+		})
+	}
+
+	return actions
+}
+
+// selectionPos converts the selected range to positions, ignoring any
+// surrounding whitespace.
+func selectionPos(doc *store.Document, rng protocol.Range) (token.Pos, token.Pos) {
+	start, end := doc.PositionToPos(rng.Start), doc.PositionToPos(rng.End)
+	if !start.IsValid() || !end.IsValid() {
+		return token.NoPos, token.NoPos
+	}
+
+	base := start - token.Pos(doc.Pgf.FileSet.Position(start).Offset)
+	for start < end && isSpace(doc.Content[int(start-base)]) {
+		start++
+	}
+	for end > start && isSpace(doc.Content[int(end-base)-1]) {
+		end--
+	}
+
+	return start, end
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// extractVariable replaces the selected expression with a new variable,
+// declared just before the enclosing statement.
+func extractVariable(doc *store.Document, ti *store.TypeInfo, start, end token.Pos) []protocol.TextEdit {
+	var expr ast.Expr
+	var stmt ast.Stmt
+	var parent ast.Node
+
+	path := enclosingNodes(doc.Pgf.File, start)
+	for i, n := range path {
+		if s, ok := n.(ast.Stmt); ok && inBlock(doc.Pgf.File, s) {
+			stmt = s
+		}
+		if e, ok := n.(ast.Expr); ok && e.Pos() == start && e.End() == end {
+			expr = e
+			if i > 0 {
+				parent = path[i-1]
+			}
+			break
+		}
+	}
+
+	if expr == nil || stmt == nil || !extractableExpr(ti, expr) || assignedTo(parent, expr) {
+		return nil
+	}
+
+	// Every variable used by the expression must already be declared before
+	// the new one.
+	for _, obj := range freeVars(ti, start, end, expr) {
+		if obj.Pos() >= stmt.Pos() {
+			return nil
+		}
+	}
+
+	name := uniqueName("x", append(namesInScope(doc, start), blockNames(ti, stmt)...))
+	indent := lineIndent(doc, stmt.Pos())
+
+	at := doc.PosToPosition(stmt.Pos())
+	return []protocol.TextEdit{
+		{
+			Range:   protocol.Range{Start: at, End: at},
+			NewText: fmt.Sprintf("%s := %s\n%s", name, nodeText(doc, expr), indent),
+		},
+		{Range: doc.NodeRange(expr), NewText: name},
+	}
+}
+
+// assignedTo reports whether the expression is used as a variable by its
+// parent (assigned to, incremented or has its address taken), in which case
+// replacing it with a copy would change what the code does.
+func assignedTo(parent ast.Node, expr ast.Expr) bool {
+	switch p := parent.(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range p.Lhs {
+			if lhs == expr {
+				return true
+			}
+		}
+	case *ast.IncDecStmt:
+		return p.X == expr
+	case *ast.RangeStmt:
+		return p.Key == expr || p.Value == expr
+	case *ast.UnaryExpr:
+		return p.Op == token.AND && p.X == expr
+	}
+	return false
+}
+
+// blockNames returns the names declared anywhere in the block that contains
+// the statement (including its nested scopes), since a new variable must
+// not clash with the ones declared after it either.
+func blockNames(ti *store.TypeInfo, stmt ast.Stmt) []string {
+	scope := ti.Pkg.Scope().Innermost(stmt.Pos())
+	if scope == nil {
+		return nil
+	} else if scope.Pos() == stmt.Pos() && scope.Parent() != nil {
+		scope = scope.Parent() // the statement's own scope, such as an `if`'s
+	}
+
+	names := []string{}
+	var walk func(*types.Scope)
+	walk = func(s *types.Scope) {
+		names = append(names, s.Names()...)
+		for i := 0; i < s.NumChildren(); i++ {
+			walk(s.Child(i))
+		}
+	}
+	walk(scope)
+
+	return names
+}
+
+// extractableExpr reports whether the expression is a value that can be
+// moved into a variable.
+func extractableExpr(ti *store.TypeInfo, expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident, *ast.KeyValueExpr, *ast.ArrayType, *ast.MapType,
+		*ast.FuncType, *ast.ChanType, *ast.InterfaceType, *ast.StructType:
+		return false
+	case *ast.CallExpr:
+		// Calls with no (or multiple) results can't be assigned to a single
+		// variable.
+		tuple, isTuple := ti.Info.TypeOf(e).(*types.Tuple)
+		return !isTuple || tuple.Len() == 1
+	default:
+		return true
+	}
+}
+
+// inBlock reports whether the statement is directly part of a statement
+// list (as opposed to, say, the init statement of an `if`).
+func inBlock(file *ast.File, stmt ast.Stmt) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		var list []ast.Stmt
+		switch node := n.(type) {
+		case *ast.BlockStmt:
+			list = node.List
+		case *ast.CaseClause:
+			list = node.Body
+		case *ast.CommClause:
+			list = node.Body
+		}
+
+		for _, s := range list {
+			if s == stmt {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// extractFunction moves the selected statements into a new function,
+// declared after the enclosing one.
+//
+// Its parameters are the local variables the statements use and its results
+// are the variables they declare that are used afterwards.
+func extractFunction(doc *store.Document, ti *store.TypeInfo, start, end token.Pos) []protocol.TextEdit {
+	decl, stmts := selectedStmts(doc.Pgf.File, start, end)
+	if len(stmts) == 0 || !selfContained(stmts) {
+		return nil
+	}
+	first, last := stmts[0], stmts[len(stmts)-1]
+
+	nodes := make([]ast.Node, 0, len(stmts))
+	for _, s := range stmts {
+		nodes = append(nodes, s)
+	}
+
+	params, ok := paramList(doc, ti, freeVars(ti, first.Pos(), last.End(), nodes...))
+	if !ok {
+		return nil
+	}
+
+	results, ok := resultVars(doc, ti, decl, first.Pos(), last.End())
+	if !ok {
+		return nil
+	}
+
+	name := uniqueName("newFunction", ti.Pkg.Scope().Names())
+	args, names, resultTypes := []string{}, []string{}, []string{}
+	for _, p := range params {
+		args = append(args, p.Name)
+	}
+	for _, r := range results {
+		names = append(names, r.Name)
+		resultTypes = append(resultTypes, r.Type)
+	}
+
+	call := fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+	if len(results) > 0 {
+		call = fmt.Sprintf("%s := %s", strings.Join(names, ", "), call)
+	}
+
+	body := reindent(nodeTextRange(doc, first.Pos(), last.End()), lineIndent(doc, first.Pos()))
+	if len(results) > 0 {
+		body += "\n\treturn " + strings.Join(names, ", ")
+	}
+
+	sig := fmt.Sprintf("func %s(%s)", name, joinParams(params))
+	switch len(resultTypes) {
+	case 0:
+	case 1:
+		sig += " " + resultTypes[0]
+	default:
+		sig += " (" + strings.Join(resultTypes, ", ") + ")"
+	}
+
+	after := doc.PosToPosition(decl.End())
+	return []protocol.TextEdit{
+		{
+			Range: protocol.Range{
+				Start: doc.PosToPosition(first.Pos()),
+				End:   doc.PosToPosition(last.End()),
+			},
+			NewText: call,
+		},
+		{
+			Range:   protocol.Range{Start: after, End: after},
+			NewText: fmt.Sprintf("\n\n%s {\n%s\n}", sig, body),
+		},
+	}
+}
+
+// selectedStmts returns the statements of a single block that the selection
+// covers, along with the enclosing function.
+func selectedStmts(file *ast.File, start, end token.Pos) (*ast.FuncDecl, []ast.Stmt) {
+	var decl *ast.FuncDecl
+	var stmts []ast.Stmt
+
+	for _, n := range enclosingNodes(file, start) {
+		var list []ast.Stmt
+		switch node := n.(type) {
+		case *ast.FuncDecl:
+			decl = node
+		case *ast.BlockStmt:
+			list = node.List
+		case *ast.CaseClause:
+			list = node.Body
+		case *ast.CommClause:
+			list = node.Body
+		}
+
+		selected := []ast.Stmt{}
+		for _, s := range list {
+			if s.Pos() >= start && s.End() <= end {
+				selected = append(selected, s)
+			} else if s.Pos() < end && s.End() > start {
+				selected = nil // partially selected
+				break
+			}
+		}
+
+		if len(selected) > 0 && selected[0].Pos() == start && selected[len(selected)-1].End() == end {
+			stmts = selected
+		}
+	}
+
+	if decl == nil || decl.Body == nil {
+		return nil, nil
+	}
+	return decl, stmts
+}
+
+// selfContained reports whether the statements can be moved into their own
+// function: they must not return from, or jump out of, the enclosing one.
+func selfContained(stmts []ast.Stmt) bool {
+	ok := true
+
+	var visit func(n ast.Node, inLoop, inSwitch bool) bool
+	visit = func(n ast.Node, inLoop, inSwitch bool) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			ok = false
+		case *ast.BranchStmt:
+			switch node.Tok {
+			case token.CONTINUE:
+				ok = ok && inLoop
+			case token.BREAK:
+				ok = ok && (inLoop || inSwitch)
+			default: // goto, fallthrough
+				ok = false
+			}
+			if node.Label != nil {
+				ok = false
+			}
+		case *ast.ForStmt, *ast.RangeStmt:
+			ast.Inspect(node, func(c ast.Node) bool {
+				return c == node || (c != nil && visit(c, true, inSwitch))
+			})
+			return false
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			ast.Inspect(node, func(c ast.Node) bool {
+				return c == node || (c != nil && visit(c, inLoop, true))
+			})
+			return false
+		}
+		return ok
+	}
+
+	for _, s := range stmts {
+		ast.Inspect(s, func(n ast.Node) bool {
+			return n != nil && visit(n, false, false)
+		})
+	}
+	return ok
+}
+
+// freeVars returns the local variables that are used within [start, end) of
+// the given nodes but declared outside of it.
+func freeVars(ti *store.TypeInfo, start, end token.Pos, nodes ...ast.Node) []*types.Var {
+	seen := map[*types.Var]bool{}
+	vars := []*types.Var{}
+	for _, n := range nodes {
+		ast.Inspect(n, func(c ast.Node) bool {
+			id, ok := c.(*ast.Ident)
+			if !ok || id.Pos() < start || id.End() > end {
+				return true
+			}
+
+			v, isVar := ti.Info.Uses[id].(*types.Var)
+			if !isVar || v.IsField() || seen[v] || !isLocal(ti, v) {
+				return true
+			}
+
+			if v.Pos() < start || v.Pos() >= end {
+				seen[v] = true
+				vars = append(vars, v)
+			}
+			return true
+		})
+	}
+	return vars
+}
+
+// isLocal reports whether the variable is declared within a function.
+func isLocal(ti *store.TypeInfo, v *types.Var) bool {
+	return v.Parent() != nil && v.Parent() != ti.Pkg.Scope() && v.Parent() != types.Universe
+}
+
+// A typedVar is a variable along with the source text of its type.
+type typedVar struct {
+	Name string
+	Type string
+}
+
+func paramList(doc *store.Document, ti *store.TypeInfo, vars []*types.Var) ([]typedVar, bool) {
+	params := []typedVar{}
+	for _, v := range vars {
+		typ := varType(doc, ti, v)
+		if typ == "" {
+			return nil, false
+		}
+		params = append(params, typedVar{Name: v.Name(), Type: typ})
+	}
+	return params, true
+}
+
+// resultVars returns the variables declared within [start, end) that are
+// used after it.
+//
+// Statements that assign to an existing variable that is used afterwards
+// can't be extracted (yet).
+func resultVars(doc *store.Document, ti *store.TypeInfo, decl *ast.FuncDecl, start, end token.Pos) ([]typedVar, bool) {
+	writes := writtenIdents(doc.Pgf.File)
+
+	seen := map[types.Object]bool{}
+	results := []typedVar{}
+	ok := true
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		id, isIdent := n.(*ast.Ident)
+		if !isIdent || id.Pos() < end {
+			return true
+		}
+
+		v, isVar := ti.Info.Uses[id].(*types.Var)
+		if !isVar || v.IsField() || seen[v] {
+			return true
+		}
+
+		switch {
+		case v.Pos() >= start && v.Pos() < end:
+			seen[v] = true
+
+			typ := varType(doc, ti, v)
+			if typ == "" {
+				ok = false
+			}
+			results = append(results, typedVar{Name: v.Name(), Type: typ})
+		case v.Pos() < start && isLocal(ti, v) && assignedWithin(ti, writes, v, start, end):
+			ok = false
+		}
+		return true
+	})
+
+	return results, ok
+}
+
+func assignedWithin(ti *store.TypeInfo, writes map[*ast.Ident]bool, v *types.Var, start, end token.Pos) bool {
+	for id := range writes {
+		if id.Pos() >= start && id.End() <= end && ti.ObjectOf(id) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// varType returns the source text of a variable's type, falling back to its
+// declaration for types the type checker doesn't know about.
+func varType(doc *store.Document, ti *store.TypeInfo, v *types.Var) string {
+	typ := types.TypeString(v.Type(), types.RelativeTo(ti.Pkg))
+	if !strings.Contains(typ, "invalid type") {
+		return typ
+	}
+
+	pkg := &store.Package{
+		FileSet: doc.Pgf.FileSet,
+		Files:   map[string]*ast.File{doc.Path: doc.Pgf.File},
+		Sources: map[string]string{doc.Path: doc.Content},
+	}
+
+	if expr := declTypeExpr(pkg, ti, doc.Pgf.File, v.Pos()); expr != nil {
+		return exprString(expr)
+	}
+	return ""
+}
+
+func joinParams(params []typedVar) string {
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		parts = append(parts, p.Name+" "+p.Type)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// uniqueName returns the base name, with a numeric suffix if needed to avoid
+// the names already in use.
+func uniqueName(base string, used []string) string {
+	taken := map[string]bool{}
+	for _, name := range used {
+		taken[name] = true
+	}
+
+	name := base
+	for i := 1; taken[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	return name
+}
+
+// lineIndent returns the leading whitespace of the line containing pos.
+func lineIndent(doc *store.Document, pos token.Pos) string {
+	line := doc.Lines[doc.PosToPosition(pos).Line]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// reindent moves the text (whose first line starts at the given indentation)
+// to a function body's indentation of a single tab.
+func reindent(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			line = strings.TrimPrefix(line, indent)
+		}
+		lines[i] = "\t" + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// nodeTextRange returns the source text between two positions.
+func nodeTextRange(doc *store.Document, start, end token.Pos) string {
+	from := doc.Pgf.FileSet.Position(start).Offset
+	to := doc.Pgf.FileSet.Position(end).Offset
+	return doc.Content[from:to]
+}
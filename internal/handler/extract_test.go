@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestExtractVariable(t *testing.T) {
+	doc := loadTestDoc(t, "extract/extract.gno")
+
+	// `len(parts) * 2`
+	actions := extractActions(doc, protocol.Range{
+		Start: protocol.Position{Line: 6, Character: 10},
+		End:   protocol.Position{Line: 6, Character: 24},
+	})
+	if len(actions) != 1 || actions[0].Title != "Extract variable" {
+		t.Fatalf("expected = %v, got = %v", "Extract variable", actions)
+	}
+
+	edits := actions[0].Edit.Changes[doc.URI]
+	if edits[0].NewText != "x := len(parts) * 2\n\t" || edits[1].NewText != "x" {
+		t.Errorf("expected = %v, got = %v", "x := len(parts) * 2", edits)
+	}
+}
+
+func TestExtractFunction(t *testing.T) {
+	doc := loadTestDoc(t, "extract/extract.gno")
+
+	// The `count` and `title` statements.
+	actions := extractActions(doc, protocol.Range{
+		Start: protocol.Position{Line: 6, Character: 0},
+		End:   protocol.Position{Line: 8, Character: 0},
+	})
+	if len(actions) != 1 || actions[0].Title != "Extract function" {
+		t.Fatalf("expected = %v, got = %v", "Extract function", actions)
+	}
+
+	edits := actions[0].Edit.Changes[doc.URI]
+	if edits[0].NewText != "count, title := newFunction(parts)" {
+		t.Errorf("expected = %v, got = %v", "count, title := newFunction(parts)", edits[0].NewText)
+	}
+
+	expected := `
+
+func newFunction(parts []string) (int, string) {
+	count := len(parts) * 2
+	title := strings.ToUpper(parts[0])
+	return count, title
+}`
+	if edits[1].NewText != expected {
+		t.Errorf("expected = %v, got = %v", expected, edits[1].NewText)
+	}
+
+	// Statements that return can't be extracted.
+	actions = extractActions(doc, protocol.Range{
+		Start: protocol.Position{Line: 13, Character: 1},
+		End:   protocol.Position{Line: 15, Character: 2},
+	})
+	if len(actions) != 0 {
+		t.Errorf("expected = %v, got = %v", 0, actions)
+	}
+}
+
+func TestExtractVariableNames(t *testing.T) {
+	doc := loadTestDoc(t, "extract/names.gno")
+
+	// `items[0] + items[1]`, before `x` is declared in the same block.
+	actions := extractActions(doc, protocol.Range{
+		Start: protocol.Position{Line: 3, Character: 10},
+		End:   protocol.Position{Line: 3, Character: 29},
+	})
+	if len(actions) != 1 || actions[0].Title != "Extract variable" {
+		t.Fatalf("expected = %v, got = %v", "Extract variable", actions)
+	}
+
+	edits := actions[0].Edit.Changes[doc.URI]
+	if edits[0].NewText != "x1 := items[0] + items[1]\n\t" || edits[1].NewText != "x1" {
+		t.Errorf("expected = %v, got = %v", "x1 := items[0] + items[1]", edits)
+	}
+
+	// Assignment targets can't be replaced by a copy.
+	for _, rng := range []protocol.Range{
+		{Start: protocol.Position{Line: 5, Character: 1}, End: protocol.Position{Line: 5, Character: 9}},
+		{Start: protocol.Position{Line: 6, Character: 1}, End: protocol.Position{Line: 6, Character: 9}},
+	} {
+		for _, action := range extractActions(doc, rng) {
+			if action.Title == "Extract variable" {
+				t.Errorf("expected = %v, got = %v", "no action", action)
+			}
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
 )
 
 func (h *handler) handleTextDocumentDidOpen(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
@@ -20,11 +22,26 @@ func (h *handler) handleTextDocumentDidOpen(ctx context.Context, reply jsonrpc2.
 		return noDocFound(ctx, reply, params.TextDocument.URI)
 	}
 
+	h.types.invalidate()
+	h.refreshTests(ctx, doc.Path)
+
 	notification := h.notifcationFromGno(ctx, h.connPool, doc)
 	return reply(ctx, notification, nil)
 }
 
-func (h *handler) handleTextDocumentDidClose(ctx context.Context, reply jsonrpc2.Replier, _ jsonrpc2.Request) error {
+func (h *handler) handleTextDocumentDidClose(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.DidCloseTextDocumentParams
+
+	// The document's unsaved changes are gone, so its tests are those on
+	// disk again.
+	if req.Params() != nil && json.Unmarshal(req.Params(), &params) == nil {
+		h.documents.Close(params.TextDocument.URI)
+		h.types.invalidate()
+		if path, err := store.CanonicalPath(params.TextDocument.URI); err == nil {
+			h.refreshTests(ctx, path)
+		}
+	}
+
 	return reply(
 		ctx,
 		h.connPool.Notify(
@@ -49,11 +66,30 @@ func (h *handler) handleTextDocumentDidSave(ctx context.Context, reply jsonrpc2.
 	if !ok {
 		return noDocFound(ctx, reply, params.TextDocument.URI)
 	}
+	h.types.invalidate()
+	h.refreshTests(ctx, doc.Path)
 
 	notification := h.notifcationFromGno(ctx, h.connPool, doc)
 	return reply(ctx, notification, nil)
 }
 
+func (h *handler) handleTextDocumentWillSaveWaitUntil(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.WillSaveTextDocumentParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	return reply(ctx, h.saveEdits(doc), nil)
+}
+
 func (h *handler) handleTextDocumentDidChange(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
 	var params protocol.DidChangeTextDocumentParams
 
@@ -68,6 +104,7 @@ func (h *handler) handleTextDocumentDidChange(ctx context.Context, reply jsonrpc
 		return noDocFound(ctx, reply, params.TextDocument.URI)
 	}
 	doc.ApplyChanges(params.ContentChanges)
+	h.types.invalidate()
 
 	return reply(ctx, nil, nil)
 }
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+
+	"github.com/jdkato/gnols/internal/gno"
+	"github.com/jdkato/gnols/internal/store"
+)
+
+var errEditNotApplied = errors.New("the client didn't apply the edit")
+
+func isFiletest(path string) bool {
+	return strings.HasSuffix(path, "_filetest.gno")
+}
+
+// filetestLenses returns the lenses that run a filetest and update its
+// golden blocks.
+func filetestLenses(path string) []protocol.CodeLens {
+	args, err := newCommandArgs(path)
+	if err != nil {
+		return []protocol.CodeLens{}
+	}
+
+	return []protocol.CodeLens{
+		newHeaderCmd("run filetest", "gnols.filetest", args),
+		newHeaderCmd("update golden output", "gnols.updateFiletest", args),
+	}
+}
+
+// runFiletest runs a filetest (or updates its golden blocks), reporting its
+// result like `runTest` does. Mismatches are shown on the directive blocks
+// they concern.
+//
+// Updates are applied by the client, as edits to the document.
+func (h *handler) runFiletest(ctx context.Context, file string, update bool, settings testSettings, token *protocol.ProgressToken) {
+	name := "file/" + filepath.Base(file)
+
+	title := "Running " + name
+	if update {
+		title = "Updating " + name
+	}
+
+	p := h.beginProgress(ctx, token, title)
+
+	var out []byte
+	var err error
+	if update {
+		out, err = h.updateFiletest(ctx, file, settings)
+	} else {
+		out, err = h.binManager.RunFiletest(file, settings.Options)
+	}
+	h.logOutput(ctx, settings, string(out))
+
+	result := gno.TestResult{Name: name, Status: "pass"}
+	mismatches := []gno.FiletestMismatch{}
+	if err != nil {
+		result.Status = "fail"
+		mismatches = gno.ParseFiletestOutput(string(out))
+		if errors.Is(err, errEditNotApplied) || len(mismatches) == 0 {
+			mismatches = []gno.FiletestMismatch{{Diff: err.Error()}}
+		}
+	}
+
+	summary := filetestSummary(name, update, mismatches)
+	p.end(ctx, summary)
+
+	msgType := protocol.MessageTypeInfo
+	if err != nil {
+		msgType = protocol.MessageTypeError
+	}
+	h.notify(ctx, protocol.MethodWindowShowMessage, protocol.ShowMessageParams{
+		Type:    msgType,
+		Message: summary,
+	})
+
+	diags := h.filetestDiagnostics(file, mismatches)
+	h.notify(ctx, protocol.MethodTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+		URI:         uri.File(file),
+		Diagnostics: h.diagnostics.setFileTests(file, diags),
+	})
+
+	h.notify(ctx, methodTestResults, testResultsParams{
+		Package: filepath.Dir(file),
+		Tests:   []string{name},
+		Passed:  err == nil,
+		Results: []gno.TestResult{result},
+		Output:  string(out),
+	})
+}
+
+// updateFiletest updates the golden blocks of a filetest with a workspace
+// edit, rather than on disk, so that the client's copy (which may have
+// unsaved changes) stays in sync.
+func (h *handler) updateFiletest(ctx context.Context, file string, settings testSettings) ([]byte, error) {
+	doc, err := h.documents.LoadDocument(file)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, out, err := h.binManager.UpdateFiletest(file, doc.Content, settings.Options)
+	if edits := diffEdits(doc.Content, updated); len(edits) > 0 {
+		edit := protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{uri.File(file): edits},
+		}
+
+		if !h.applyEdit(ctx, "Update golden output", edit) {
+			return out, errEditNotApplied
+		}
+	}
+
+	return out, err
+}
+
+func filetestSummary(name string, update bool, mismatches []gno.FiletestMismatch) string {
+	if len(mismatches) == 0 {
+		if update {
+			return "Updated the golden output of " + name
+		}
+		return "PASS: " + name
+	}
+
+	directives := []string{}
+	for _, m := range mismatches {
+		if m.Directive != "" {
+			directives = append(directives, m.Directive)
+		}
+	}
+
+	if len(directives) == 0 {
+		return "FAIL: " + name
+	}
+	return fmt.Sprintf("FAIL: %s (%s mismatch)", name, strings.Join(directives, ", "))
+}
+
+// filetestDiagnostics places each mismatch on its directive block, or on
+// the first line if the file has no such block.
+func (h *handler) filetestDiagnostics(file string, mismatches []gno.FiletestMismatch) []protocol.Diagnostic {
+	diags := []protocol.Diagnostic{}
+	if len(mismatches) == 0 {
+		return diags
+	}
+
+	doc, err := h.documents.LoadDocument(file)
+	if err != nil {
+		return diags
+	}
+	directives := gno.FiletestDirectives(doc.Content)
+
+	for _, m := range mismatches {
+		rng := protocol.Range{End: lineEnd(doc, 0)}
+		for _, d := range directives {
+			if d.Name == m.Directive {
+				rng = protocol.Range{
+					Start: protocol.Position{Line: uint32(d.StartLine)},
+					End:   lineEnd(doc, d.EndLine),
+				}
+			}
+		}
+
+		msg := strings.TrimSpace(m.Diff)
+		if m.Directive != "" {
+			msg = m.Directive + " mismatch:\n" + msg
+		}
+
+		diags = append(diags, protocol.Diagnostic{
+			Range:    rng,
+			Severity: protocol.DiagnosticSeverityError,
+			Source:   "gnols",
+			Code:     "filetest",
+			Message:  msg,
+		})
+	}
+
+	return diags
+}
+
+// lineEnd returns the position of the end of the given line (excluding its
+// line ending).
+func lineEnd(doc *store.Document, line int) protocol.Position {
+	if line >= len(doc.Lines) {
+		return protocol.Position{Line: uint32(line)}
+	}
+
+	text := strings.TrimRight(doc.Lines[line], "\r\n")
+	return protocol.Position{
+		Line:      uint32(line),
+		Character: uint32(len(utf16.Encode([]rune(text)))),
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/gno"
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestFiletestDiagnostics(t *testing.T) {
+	file, err := filepath.Abs("../../testdata/filetest/z0_filetest.gno")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &handler{documents: store.NewDocumentStore()}
+	diags := h.filetestDiagnostics(file, []gno.FiletestMismatch{
+		{Directive: "Output", Diff: "-41\n+42\n"},
+		{Diff: "panic: oops"},
+	})
+	if len(diags) != 2 {
+		t.Fatalf("expected = %v, got = %v", 2, len(diags))
+	}
+
+	// The `// Output:` block.
+	expected := protocol.Range{
+		Start: protocol.Position{Line: 8},
+		End:   protocol.Position{Line: 10, Character: 7},
+	}
+	if diags[0].Range != expected {
+		t.Errorf("expected = %v, got = %v", expected, diags[0].Range)
+	}
+
+	if diags[0].Message != "Output mismatch:\n-41\n+42" {
+		t.Errorf("expected = %v, got = %v", "Output mismatch:\n-41\n+42", diags[0].Message)
+	}
+
+	if diags[1].Range.Start.Line != 0 || diags[1].Message != "panic: oops" {
+		t.Errorf("expected = %v, got = %v", "panic: oops", diags[1])
+	}
+}
+
+func TestFiletestLenses(t *testing.T) {
+	lenses := filetestLenses("/p/z0_filetest.gno")
+	if len(lenses) != 2 {
+		t.Fatalf("expected = %v, got = %v", 2, len(lenses))
+	}
+
+	if lenses[1].Command.Command != "gnols.updateFiletest" {
+		t.Errorf("expected = %v, got = %v", "gnols.updateFiletest", lenses[1].Command.Command)
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"regexp"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// directiveRe matches the comments that start an expected-output section in
+// a filetest (e.g., `// Output:`).
+var directiveRe = regexp.MustCompile(`^//\s*(Output|Error|Realm|Events):`)
+
+func (h *handler) handleFoldingRange(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.FoldingRangeParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	if doc.Pgf == nil || doc.Pgf.FileSet == nil {
+		return reply(ctx, []protocol.FoldingRange{}, nil)
+	}
+
+	return reply(ctx, foldingRanges(doc), nil)
+}
+
+// foldingRanges returns the foldable regions of the document: import
+// blocks, function bodies and other blocks, multi-line composite literals,
+// comment blocks and filetest directives.
+func foldingRanges(doc *store.Document) []protocol.FoldingRange {
+	ranges := []protocol.FoldingRange{}
+
+	add := func(start, end token.Pos, kind protocol.FoldingRangeKind, keepLast bool) {
+		from := doc.PosToPosition(start)
+		to := doc.PosToPosition(end)
+
+		// Keep the closing delimiter visible.
+		if keepLast {
+			to.Line--
+		}
+
+		if to.Line > from.Line {
+			ranges = append(ranges, protocol.FoldingRange{
+				StartLine:      from.Line,
+				StartCharacter: from.Character,
+				EndLine:        to.Line,
+				Kind:           kind,
+			})
+		}
+	}
+
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.GenDecl:
+			if node.Lparen.IsValid() {
+				kind := protocol.RegionFoldingRange
+				if node.Tok == token.IMPORT {
+					kind = protocol.ImportsFoldingRange
+				}
+				add(node.Lparen, node.Rparen, kind, true)
+			}
+		case *ast.BlockStmt:
+			add(node.Lbrace, node.Rbrace, protocol.RegionFoldingRange, true)
+		case *ast.CompositeLit:
+			add(node.Lbrace, node.Rbrace, protocol.RegionFoldingRange, true)
+		case *ast.CaseClause:
+			add(node.Colon, node.End(), protocol.RegionFoldingRange, false)
+		case *ast.CommClause:
+			add(node.Colon, node.End(), protocol.RegionFoldingRange, false)
+		}
+		return true
+	})
+
+	// Filetest directives get their own range, so that one section (e.g.,
+	// `// Realm:`) can be folded on its own.
+	isTest := isFiletest(doc.Path)
+	for _, group := range doc.Pgf.File.Comments {
+		if !isTest || !directiveRe.MatchString(group.List[0].Text) {
+			add(group.Pos(), group.End(), protocol.CommentFoldingRange, false)
+		}
+
+		if !isTest {
+			continue
+		}
+
+		for i, c := range group.List {
+			if !directiveRe.MatchString(c.Text) {
+				continue
+			}
+
+			end := group.End()
+			for _, next := range group.List[i+1:] {
+				if directiveRe.MatchString(next.Text) {
+					break
+				}
+				end = next.End()
+			}
+
+			add(c.Pos(), end, protocol.RegionFoldingRange, false)
+		}
+	}
+
+	return ranges
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestFoldingRanges(t *testing.T) {
+	doc := loadTestDoc(t, "folding/z0_filetest.gno")
+
+	type key struct {
+		start, end uint32
+		kind       protocol.FoldingRangeKind
+	}
+	expected := []key{
+		{2, 3, protocol.ImportsFoldingRange},
+		{6, 7, protocol.CommentFoldingRange},
+		{8, 13, protocol.RegionFoldingRange},  // func body
+		{9, 11, protocol.RegionFoldingRange},  // composite literal
+		{16, 17, protocol.RegionFoldingRange}, // Output:
+		{19, 20, protocol.RegionFoldingRange}, // Error:
+	}
+
+	found := map[key]bool{}
+	for _, rng := range foldingRanges(doc) {
+		found[key{rng.StartLine, rng.EndLine, rng.Kind}] = true
+	}
+
+	for _, k := range expected {
+		if !found[k] {
+			t.Errorf("expected a %v range at %d-%d, got = %v", k.kind, k.start, k.end, found)
+		}
+	}
+
+	if len(found) != len(expected) {
+		t.Errorf("expected = %v, got = %v", len(expected), len(found))
+	}
+}
+
+func TestSelectionRange(t *testing.T) {
+	doc := loadTestDoc(t, "folding/z0_filetest.gno")
+
+	// The `"%v"` argument.
+	rng := selectionRange(doc, protocol.Position{Line: 13, Character: 23})
+	if rng.Range.Start.Character != 22 || rng.Range.End.Character != 26 {
+		t.Errorf("expected the string literal, got = %v", rng.Range)
+	}
+
+	depth := 0
+	for p := &rng; p != nil; p = p.Parent {
+		depth++
+	}
+
+	if depth < 5 {
+		t.Errorf("expected at least %d levels, got = %d", 5, depth)
+	}
+}
+
+func TestFoldingRangesTestFile(t *testing.T) {
+	doc := loadTestDoc(t, "folding/example_test.gno")
+
+	kinds := []protocol.FoldingRangeKind{}
+	for _, rng := range foldingRanges(doc) {
+		if rng.StartLine == 4 {
+			kinds = append(kinds, rng.Kind)
+		}
+	}
+
+	if len(kinds) != 1 || kinds[0] != protocol.CommentFoldingRange {
+		t.Errorf("expected = %v, got = %v", []protocol.FoldingRangeKind{protocol.CommentFoldingRange}, kinds)
+	}
+}
@@ -3,11 +3,14 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
-	"unicode/utf8"
 
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/gno"
+	"github.com/jdkato/gnols/internal/store"
 )
 
 func (h *handler) handleTextDocumentFormatting(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
@@ -24,34 +27,171 @@ func (h *handler) handleTextDocumentFormatting(ctx context.Context, reply jsonrp
 		return noDocFound(ctx, reply, params.TextDocument.URI)
 	}
 
-	if h.binManager == nil {
-		slog.Warn("diagnostics", "no bin manager", h.binManager)
+	edits, err := h.formatEdits(doc)
+	if err != nil {
+		h.showFormatError(ctx, err)
+		return reply(ctx, nil, err)
+	}
+
+	return reply(ctx, edits, nil)
+}
+
+func (h *handler) handleRangeFormatting(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.DocumentRangeFormattingParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	edits, err := h.formatEdits(doc)
+	if err != nil {
+		h.showFormatError(ctx, err)
+		return reply(ctx, nil, err)
+	}
+
+	return reply(ctx, editsWithin(edits, params.Range.Start.Line, params.Range.End.Line), nil)
+}
+
+func (h *handler) handleOnTypeFormatting(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.DocumentOnTypeFormattingParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	// The document is often incomplete while typing, so a failure to format
+	// it isn't worth reporting.
+	edits, err := h.formatEdits(doc)
+	if err != nil {
 		return reply(ctx, []protocol.TextEdit{}, nil)
 	}
-	slog.Info("formatting", "pre", doc.Content)
+
+	line := params.Position.Line
+	first := line
+	switch params.Ch {
+	case "}":
+		first = openingBraceLine(doc, params.Position)
+	case "\n":
+		if line > 0 {
+			first = line - 1
+		}
+	}
+
+	return reply(ctx, editsWithin(edits, first, line), nil)
+}
+
+// formatEdits formats the document, returning the minimal edits that turn
+// its content into the formatted one.
+func (h *handler) formatEdits(doc *store.Document) ([]protocol.TextEdit, error) {
+	if h.binManager == nil {
+		slog.Warn("formatting", "no bin manager", h.binManager)
+		return []protocol.TextEdit{}, nil
+	}
 
 	formatted, err := h.binManager.Format(doc.Content)
 	if err != nil {
 		slog.Error("formatting", "error", err, "text", formatted)
-		return reply(ctx, nil, err)
+		return nil, err
 	}
 
-	slog.Info("formatting", "post", formatted)
-
-	lastLine := len(doc.Lines) - 1
-	lastChar := utf8.RuneCountInString(doc.Lines[lastLine])
-
-	slog.Info("formatting", "lastLine", lastLine, "lastChar", lastChar)
-	return reply(ctx, []protocol.TextEdit{
-		{
-			Range: protocol.Range{
-				Start: protocol.Position{Line: 0, Character: 0},
-				End: protocol.Position{
-					Line:      uint32(lastLine),
-					Character: uint32(lastChar),
-				},
-			},
-			NewText: string(formatted),
-		},
-	}, nil)
+	edits := diffEdits(doc.Content, string(formatted))
+	slog.Info("formatting", "edits", len(edits))
+
+	return edits, nil
+}
+
+// saveEdits returns the edits to make before the document is saved: its
+// imports are organized (if `organizeImportsOnSave` is set) and then it's
+// formatted.
+//
+// A document that doesn't parse is saved as is, and formatting failures
+// don't prevent the save, so they're only logged.
+func (h *handler) saveEdits(doc *store.Document) []protocol.TextEdit {
+	if !importsEditable(doc) {
+		return []protocol.TextEdit{}
+	}
+
+	content := doc.Content
+	if h.organizeOnSave {
+		content = applyEdits(content, organizeImports(doc))
+	}
+
+	if h.binManager != nil {
+		formatted, err := h.binManager.Format(content)
+		if err != nil {
+			slog.Warn("formatting", "error", err)
+		} else {
+			content = string(formatted)
+		}
+	}
+
+	return diffEdits(doc.Content, content)
+}
+
+// showFormatError tells the user why their document couldn't be formatted,
+// since clients tend to only log failed requests.
+func (h *handler) showFormatError(ctx context.Context, err error) {
+	var formatErr *gno.FormatError
+	if !errors.As(err, &formatErr) {
+		return
+	}
+
+	h.notify(ctx, protocol.MethodWindowShowMessage, protocol.ShowMessageParams{
+		Type:    protocol.MessageTypeError,
+		Message: "Formatting failed: " + formatErr.Error(),
+	})
+}
+
+// editsWithin returns the edits that touch the lines [first, last].
+func editsWithin(edits []protocol.TextEdit, first, last uint32) []protocol.TextEdit {
+	within := []protocol.TextEdit{}
+	for _, edit := range edits {
+		start, end := edit.Range.Start.Line, edit.Range.End.Line
+		if end > start && edit.Range.End.Character == 0 {
+			end-- // the edit ends at the start of the next line
+		}
+		if start <= last && end >= first {
+			within = append(within, edit)
+		}
+	}
+	return within
+}
+
+// openingBraceLine returns the line of the `{` matching the `}` just before
+// the given position.
+func openingBraceLine(doc *store.Document, pos protocol.Position) uint32 {
+	depth := 0
+	for line := int(pos.Line); line >= 0 && line < len(doc.Lines); line-- {
+		text := []rune(doc.Lines[line])
+		if line == int(pos.Line) {
+			text = text[:min(int(pos.Character), len(text))]
+		}
+
+		for i := len(text) - 1; i >= 0; i-- {
+			switch text[i] {
+			case '}':
+				depth++
+			case '{':
+				depth--
+				if depth == 0 {
+					return uint32(line)
+				}
+			}
+		}
+	}
+	return pos.Line
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"slices"
+	"sync/atomic"
 
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
@@ -16,6 +18,39 @@ type handler struct {
 	connPool   jsonrpc2.Conn
 	documents  *store.DocumentStore
 	binManager *gno.BinManager
+
+	rootDir        string    // workspace root, if any
+	snippetSupport bool      // whether the client supports snippets
+	snippets       []snippet // user-defined snippets
+
+	usePlaceholders bool // whether to complete calls with placeholders
+	organizeOnSave  bool // whether to organize imports before saving
+
+	semantic    semanticCache
+	hints       hintSettings
+	diagnostics diagnosticCache
+	tests       testTree
+	types       typeIndexCache
+
+	testing          testSettings // how to run tests
+	coverage         atomic.Bool  // whether to collect coverage when testing
+	workDoneProgress bool         // whether the client supports `$/progress`
+	createFiles      bool         // whether the client can create files in workspace edits
+	watchFiles       bool         // whether the client can watch files for us
+	progressID       atomic.Int64 // the last server-created progress token
+}
+
+// serverCapabilities extends `protocol.ServerCapabilities` with the
+// capabilities added after LSP 3.16.
+type serverCapabilities struct {
+	protocol.ServerCapabilities
+
+	InlayHintProvider     bool `json:"inlayHintProvider,omitempty"`
+	TypeHierarchyProvider bool `json:"typeHierarchyProvider,omitempty"`
+}
+
+type initializeResult struct {
+	Capabilities serverCapabilities `json:"capabilities"`
 }
 
 func NewHandler(connPool jsonrpc2.Conn) jsonrpc2.Handler {
@@ -33,6 +68,11 @@ func (h *handler) handle(ctx context.Context, reply jsonrpc2.Replier, req jsonrp
 	case protocol.MethodInitialize:
 		return h.handleInitialize(ctx, reply, req)
 	case protocol.MethodInitialized:
+		if h.watchFiles {
+			// Registering is a request to the client, so it can't block
+			// the read loop.
+			go h.watchGnoFiles(context.WithoutCancel(ctx))
+		}
 		return reply(ctx, nil, nil)
 	case protocol.MethodShutdown:
 		return h.handleShutdown(ctx, reply, req)
@@ -42,6 +82,8 @@ func (h *handler) handle(ctx context.Context, reply jsonrpc2.Replier, req jsonrp
 		return h.handleTextDocumentDidClose(ctx, reply, req)
 	case protocol.MethodTextDocumentDidChange:
 		return h.handleTextDocumentDidChange(ctx, reply, req)
+	case protocol.MethodTextDocumentWillSaveWaitUntil:
+		return h.handleTextDocumentWillSaveWaitUntil(ctx, reply, req)
 	case protocol.MethodTextDocumentDidSave:
 		return h.handleTextDocumentDidSave(ctx, reply, req)
 	case protocol.MethodTextDocumentCompletion:
@@ -54,6 +96,46 @@ func (h *handler) handle(ctx context.Context, reply jsonrpc2.Replier, req jsonrp
 		return h.handleExecuteCommand(ctx, reply, req)
 	case protocol.MethodTextDocumentFormatting:
 		return h.handleTextDocumentFormatting(ctx, reply, req)
+	case protocol.MethodTextDocumentRangeFormatting:
+		return h.handleRangeFormatting(ctx, reply, req)
+	case protocol.MethodTextDocumentOnTypeFormatting:
+		return h.handleOnTypeFormatting(ctx, reply, req)
+	case protocol.MethodSemanticTokensFull:
+		return h.handleSemanticTokensFull(ctx, reply, req)
+	case protocol.MethodSemanticTokensFullDelta:
+		return h.handleSemanticTokensDelta(ctx, reply, req)
+	case protocol.MethodSemanticTokensRange:
+		return h.handleSemanticTokensRange(ctx, reply, req)
+	case methodTextDocumentInlayHint:
+		return h.handleInlayHint(ctx, reply, req)
+	case protocol.MethodTextDocumentFoldingRange:
+		return h.handleFoldingRange(ctx, reply, req)
+	case methodTextDocumentSelectionRange:
+		return h.handleSelectionRange(ctx, reply, req)
+	case protocol.MethodTextDocumentDocumentHighlight:
+		return h.handleDocumentHighlight(ctx, reply, req)
+	case protocol.MethodTextDocumentPrepareCallHierarchy:
+		return h.handlePrepareCallHierarchy(ctx, reply, req)
+	case protocol.MethodCallHierarchyIncomingCalls:
+		return h.handleIncomingCalls(ctx, reply, req)
+	case protocol.MethodCallHierarchyOutgoingCalls:
+		return h.handleOutgoingCalls(ctx, reply, req)
+	case protocol.MethodTextDocumentCodeAction:
+		return h.handleCodeAction(ctx, reply, req)
+	case protocol.MethodTextDocumentTypeDefinition:
+		return h.handleTypeDefinition(ctx, reply, req)
+	case protocol.MethodTextDocumentImplementation:
+		return h.handleImplementation(ctx, reply, req)
+	case methodTextDocumentPrepareTypeHierarchy:
+		return h.handlePrepareTypeHierarchy(ctx, reply, req)
+	case methodTypeHierarchySupertypes:
+		return h.handleSupertypes(ctx, reply, req)
+	case methodTypeHierarchySubtypes:
+		return h.handleSubtypes(ctx, reply, req)
+	case methodDiscoverTests:
+		return h.handleDiscoverTests(ctx, reply, req)
+	case protocol.MethodWorkspaceDidChangeWatchedFiles:
+		return h.handleDidChangeWatchedFiles(ctx, reply, req)
 	case protocol.MethodWorkspaceDidChangeConfiguration:
 		return h.handleDidChangeConfiguration(ctx, reply, req)
 	default:
@@ -67,33 +149,91 @@ func (h *handler) handleInitialize(ctx context.Context, reply jsonrpc2.Replier,
 	if err := json.Unmarshal(req.Params(), &params); err != nil {
 		return badJSON(ctx, reply, err)
 	}
+	h.rootDir = rootFromParams(params)
 
-	return reply(ctx, protocol.InitializeResult{
-		Capabilities: protocol.ServerCapabilities{
-			TextDocumentSync: protocol.TextDocumentSyncOptions{
-				Change:    protocol.TextDocumentSyncKindFull,
-				OpenClose: true,
-				Save: &protocol.SaveOptions{
-					IncludeText: true,
-				},
-			},
-			CompletionProvider: &protocol.CompletionOptions{
-				TriggerCharacters: []string{"."},
-				ResolveProvider:   false,
+	if td := params.Capabilities.TextDocument; td != nil && td.Completion != nil {
+		item := td.Completion.CompletionItem
+		h.snippetSupport = item != nil && item.SnippetSupport
+	}
+
+	if w := params.Capabilities.Window; w != nil {
+		h.workDoneProgress = w.WorkDoneProgress
+	}
+
+	if w := params.Capabilities.Workspace; w != nil {
+		if w.ApplyEdit && w.WorkspaceEdit != nil {
+			h.createFiles = slices.Contains(w.WorkspaceEdit.ResourceOperations, string(protocol.CreateResourceOperation))
+		}
+		h.watchFiles = w.DidChangeWatchedFiles != nil && w.DidChangeWatchedFiles.DynamicRegistration
+	}
+
+	snippets, err := loadSnippets(h.rootDir)
+	if err != nil {
+		slog.Warn("snippets", "err", err)
+	}
+	h.snippets = snippets
+
+	return reply(ctx, initializeResult{
+		Capabilities: serverCapabilities{
+			ServerCapabilities:    capabilities(),
+			InlayHintProvider:     true,
+			TypeHierarchyProvider: true,
+		},
+	}, nil)
+}
+
+// capabilities returns the LSP 3.16 capabilities supported by the server.
+func capabilities() protocol.ServerCapabilities {
+	return protocol.ServerCapabilities{
+		TextDocumentSync: protocol.TextDocumentSyncOptions{
+			Change:            protocol.TextDocumentSyncKindFull,
+			OpenClose:         true,
+			WillSaveWaitUntil: true,
+			Save: &protocol.SaveOptions{
+				IncludeText: true,
 			},
-			HoverProvider: true,
-			ExecuteCommandProvider: &protocol.ExecuteCommandOptions{
-				Commands: []string{
-					"gnols.gnofmt",
-					"gnols.test",
-				},
+		},
+		CompletionProvider: &protocol.CompletionOptions{
+			TriggerCharacters: []string{"."},
+			ResolveProvider:   false,
+		},
+		HoverProvider: true,
+		ExecuteCommandProvider: &protocol.ExecuteCommandOptions{
+			Commands: []string{
+				"gnols.test",
+				"gnols.bench",
+				"gnols.filetest",
+				"gnols.updateFiletest",
+				"gnols.clearCoverage",
+				"gnols.generateTest",
 			},
-			CodeLensProvider: &protocol.CodeLensOptions{
-				ResolveProvider: true,
+		},
+		CodeLensProvider: &protocol.CodeLensOptions{
+			ResolveProvider: true,
+		},
+		DocumentFormattingProvider:      true,
+		DocumentRangeFormattingProvider: true,
+		DocumentOnTypeFormattingProvider: &protocol.DocumentOnTypeFormattingOptions{
+			FirstTriggerCharacter: "}",
+			MoreTriggerCharacter:  []string{"\n"},
+		},
+		SemanticTokensProvider:    semanticTokensProvider(),
+		FoldingRangeProvider:      true,
+		SelectionRangeProvider:    true,
+		DocumentHighlightProvider: true,
+		CallHierarchyProvider:     true,
+		ImplementationProvider:    true,
+		TypeDefinitionProvider:    true,
+		CodeActionProvider: &protocol.CodeActionOptions{
+			CodeActionKinds: []protocol.CodeActionKind{
+				protocol.QuickFix,
+				protocol.RefactorExtract,
+				protocol.RefactorRewrite,
+				protocol.SourceOrganizeImports,
+				codeActionKindAddTest,
 			},
-			DocumentFormattingProvider: true,
 		},
-	}, nil)
+	}
 }
 
 func (h *handler) handleShutdown(ctx context.Context, reply jsonrpc2.Replier, _ jsonrpc2.Request) error {
@@ -0,0 +1,436 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+
+	"github.com/jdkato/gnols/internal/stdlib"
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// A typeKey identifies a named type across the workspace and the symbol
+// index.
+type typeKey struct {
+	Pkg  string `json:"pkg"` // import path (or directory, for packages without one)
+	Name string `json:"name"`
+}
+
+// A namedType is a type declaration along with its method set.
+type namedType struct {
+	Key     typeKey
+	Iface   bool
+	Indexed bool                          // whether it comes from the symbol index
+	Methods map[string]*protocol.Location // nil if the location is unknown
+	Sigs    map[string]string             // interface method signatures, e.g. `Name() string`
+	Loc     *protocol.Location            // nil if the location is unknown
+}
+
+// location returns the location of the type's declaration (or of one of its
+// methods), if known.
+func (t namedType) location(method string) *protocol.Location {
+	if method != "" {
+		return t.Methods[method]
+	} else if t.Loc == nil && t.Indexed {
+		return gnoRootDecl(t.Key.Pkg, t.Key.Name)
+	}
+	return t.Loc
+}
+
+// typeIndex is a method-set index of the named types in the workspace and
+// in the symbol index.
+//
+// Since Gno-only packages can't be type-checked, we match method sets by
+// name: a type implements an interface if it has a method with the same
+// name as each of the interface's methods.
+type typeIndex struct {
+	types []namedType
+}
+
+func buildTypeIndex(pkgs []*store.Package) *typeIndex {
+	idx := &typeIndex{}
+
+	local := map[string]bool{}
+	for _, pkg := range pkgs {
+		local[packageDetail(pkg)] = true
+		idx.types = append(idx.types, workspaceTypes(pkg)...)
+	}
+
+	for _, p := range stdlib.Packages {
+		if !local[p.ImportPath] {
+			idx.types = append(idx.types, indexedTypes(p)...)
+		}
+	}
+
+	return idx
+}
+
+// workspaceTypes returns the package's named types, using its type
+// information.
+func workspaceTypes(pkg *store.Package) []namedType {
+	found := []namedType{}
+
+	ti := pkg.TypeInfo()
+	if ti.Pkg == nil {
+		return found
+	}
+
+	scope := ti.Pkg.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			continue
+		}
+
+		t := namedType{
+			Key:     typeKey{Pkg: packageDetail(pkg), Name: name},
+			Methods: map[string]*protocol.Location{},
+			Sigs:    map[string]string{},
+			Loc:     pkgLocation(pkg, obj.Pos(), name),
+		}
+
+		if iface, isIface := obj.Type().Underlying().(*types.Interface); isIface {
+			t.Iface = true
+			for i := 0; i < iface.NumMethods(); i++ {
+				m := iface.Method(i)
+				t.Methods[m.Name()] = pkgLocation(pkg, m.Pos(), m.Name())
+				t.Sigs[m.Name()] = methodSig(pkg, m.Pos(), m.Name())
+			}
+		} else {
+			mset := types.NewMethodSet(types.NewPointer(obj.Type()))
+			for i := 0; i < mset.Len(); i++ {
+				m := mset.At(i).Obj()
+				t.Methods[m.Name()] = pkgLocation(pkg, m.Pos(), m.Name())
+			}
+		}
+
+		found = append(found, t)
+	}
+
+	return found
+}
+
+// indexedTypes returns the named types of a package in the symbol index.
+func indexedTypes(p stdlib.Package) []namedType {
+	found := []namedType{}
+	for _, s := range p.Symbols {
+		t := namedType{
+			Key:     typeKey{Pkg: p.ImportPath, Name: s.Name},
+			Indexed: true,
+			Methods: map[string]*protocol.Location{},
+			Sigs:    map[string]string{},
+		}
+
+		switch s.Kind {
+		case "interface":
+			t.Iface = true
+			for _, m := range s.Methods {
+				t.Methods[m.Name] = nil
+				t.Sigs[m.Name] = m.Signature
+			}
+		case "struct", "type", "array", "map", "chan":
+			for _, m := range p.MethodsOf(s.Name) {
+				t.Methods[m.Name] = nil
+			}
+		default:
+			continue
+		}
+
+		found = append(found, t)
+	}
+	return found
+}
+
+func (idx *typeIndex) lookup(key typeKey) (namedType, bool) {
+	for _, t := range idx.types {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return namedType{}, false
+}
+
+// implementations returns the concrete types that implement the interface.
+func (idx *typeIndex) implementations(iface namedType) []namedType {
+	found := []namedType{}
+	for _, t := range idx.types {
+		if !t.Iface && implements(t, iface) {
+			found = append(found, t)
+		}
+	}
+	return found
+}
+
+// interfacesOf returns the interfaces implemented by the type (or, for an
+// interface, the interfaces it embeds).
+func (idx *typeIndex) interfacesOf(t namedType) []namedType {
+	found := []namedType{}
+	for _, other := range idx.types {
+		if other.Iface && other.Key != t.Key && implements(t, other) {
+			found = append(found, other)
+		}
+	}
+	return found
+}
+
+// implements reports whether t has every method of iface. Empty interfaces
+// are skipped, since everything implements them.
+func implements(t, iface namedType) bool {
+	if len(iface.Methods) == 0 {
+		return false
+	}
+
+	for name := range iface.Methods {
+		if _, ok := t.Methods[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *handler) handleImplementation(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.ImplementationParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	idx, key, method, err := h.typeAt(doc, params.Position)
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	locations := []protocol.Location{}
+
+	t, found := idx.lookup(key)
+	if !found {
+		return reply(ctx, locations, nil)
+	}
+
+	related := idx.implementations(t)
+	if !t.Iface {
+		related = idx.interfacesOf(t)
+	}
+
+	for _, other := range related {
+		if loc := other.location(method); loc != nil {
+			locations = append(locations, *loc)
+		}
+	}
+
+	return reply(ctx, locations, nil)
+}
+
+// typeAt resolves the named type at the given position, along with the
+// method name if the position is on a method.
+func (h *handler) typeAt(doc *store.Document, pos protocol.Position) (*typeIndex, typeKey, string, error) {
+	idx, err := h.typeIndex(doc.Path)
+	if err != nil {
+		return nil, typeKey{}, "", err
+	}
+
+	pkg, err := h.documents.LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		return nil, typeKey{}, "", err
+	}
+
+	file, id, sel := pkgIdentAt(pkg, doc.Path, docOffset(doc, pos))
+	if id == nil {
+		return idx, typeKey{}, "", nil
+	}
+
+	ti := pkg.TypeInfo()
+	switch obj := ti.ObjectOf(id).(type) {
+	case *types.TypeName:
+		if obj.Pkg() == ti.Pkg {
+			return idx, typeKey{Pkg: packageDetail(pkg), Name: obj.Name()}, "", nil
+		}
+	case *types.Func:
+		if recv := namedRecv(obj); recv != nil && recv.Obj().Pkg() == ti.Pkg {
+			return idx, typeKey{Pkg: packageDetail(pkg), Name: recv.Obj().Name()}, obj.Name(), nil
+		}
+	}
+
+	// A type from another package, such as `grc20.IGRC20`.
+	if sel != nil && sel.Sel == id {
+		if x, isIdent := sel.X.(*ast.Ident); isIdent {
+			specs := importsNamed(file, x.Name)
+			if len(specs) > 0 {
+				path, _ := strconv.Unquote(specs[0].Path.Value)
+				return idx, typeKey{Pkg: path, Name: id.Name}, "", nil
+			}
+		}
+	}
+
+	return idx, typeKey{}, "", nil
+}
+
+// typeIndexCache holds the last type index built, until a file changes.
+type typeIndexCache struct {
+	mu   sync.Mutex
+	root string
+	idx  *typeIndex
+}
+
+// invalidate drops the cached index; it's called whenever a file changes.
+func (c *typeIndexCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.idx = nil
+}
+
+// typeIndex returns the type index for the workspace containing the given
+// file, building it if it isn't cached.
+func (h *handler) typeIndex(path string) (*typeIndex, error) {
+	root := h.rootDir
+	if root == "" {
+		root = filepath.Dir(path)
+	}
+
+	h.types.mu.Lock()
+	defer h.types.mu.Unlock()
+
+	if h.types.idx != nil && h.types.root == root {
+		return h.types.idx, nil
+	}
+
+	pkgs, err := h.documents.LoadWorkspace(root)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := buildTypeIndex(pkgs)
+	slog.Debug("type_index", "packages", len(pkgs), "types", len(idx.types))
+
+	h.types.root, h.types.idx = root, idx
+	return idx, nil
+}
+
+// namedRecv returns the named type a method is declared on (for interface
+// methods, the interface itself).
+func namedRecv(fn *types.Func) *types.Named {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return nil
+	}
+
+	t := sig.Recv().Type()
+	if ptr, isPtr := t.(*types.Pointer); isPtr {
+		t = ptr.Elem()
+	}
+
+	named, _ := t.(*types.Named)
+	return named
+}
+
+// methodSig returns the source text of the interface method declared at the
+// given position, without the `func` keyword (`Name() string`).
+func methodSig(pkg *store.Package, pos token.Pos, name string) string {
+	file := pkg.Files[pkg.FileOf(pos)]
+	if file == nil {
+		return ""
+	}
+
+	sig := ""
+	for _, n := range enclosingNodes(file, pos) {
+		if field, ok := n.(*ast.Field); ok {
+			if fn, isFunc := field.Type.(*ast.FuncType); isFunc {
+				sig = name + strings.TrimPrefix(exprString(fn), "func")
+			}
+		}
+	}
+	return sig
+}
+
+func pkgLocation(pkg *store.Package, pos token.Pos, name string) *protocol.Location {
+	path := pkg.FileOf(pos)
+	if path == "" {
+		return nil
+	}
+	return &protocol.Location{
+		URI:   uri.File(path),
+		Range: pkg.Range(&ast.Ident{NamePos: pos, Name: name}),
+	}
+}
+
+// gnoRootDecl finds the declaration of an indexed type in a local copy of
+// the Gno repository (`$GNOROOT`), if there is one.
+func gnoRootDecl(importPath, name string) *protocol.Location {
+	root := os.Getenv("GNOROOT")
+	if root == "" {
+		return nil
+	}
+
+	for _, dir := range []string{
+		filepath.Join(root, "examples", filepath.FromSlash(importPath)),
+		filepath.Join(root, "gnovm", "stdlibs", filepath.FromSlash(importPath)),
+	} {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.gno"))
+		sort.Strings(matches)
+
+		for _, path := range matches {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, path, src, 0)
+			if err != nil {
+				continue
+			}
+
+			spec := findTypeSpec(file, name)
+			if spec == nil {
+				continue
+			}
+
+			pkg := &store.Package{
+				FileSet: fset,
+				Sources: map[string]string{path: string(src)},
+			}
+			return pkgLocation(pkg, spec.Name.Pos(), name)
+		}
+	}
+
+	return nil
+}
+
+// findTypeSpec finds the declaration of the named type in the file.
+func findTypeSpec(file *ast.File, name string) *ast.TypeSpec {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			if ts, isType := spec.(*ast.TypeSpec); isType && ts.Name.Name == name {
+				return ts
+			}
+		}
+	}
+	return nil
+}
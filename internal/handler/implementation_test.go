@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestTypeIndex(t *testing.T) {
+	pkgs, err := store.NewDocumentStore().LoadWorkspace("../../testdata/implementation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := buildTypeIndex(pkgs)
+
+	shape, found := idx.lookup(typeKey{Pkg: "gno.land/p/demo/shapes", Name: "Shape"})
+	if !found || !shape.Iface {
+		t.Fatalf("expected = %v, got = %v", "Shape", shape)
+	}
+
+	impls := []string{}
+	for _, impl := range idx.implementations(shape) {
+		if impl.Key.Pkg != shape.Key.Pkg {
+			continue
+		}
+
+		impls = append(impls, impl.Key.Name)
+		if impl.location("Area") == nil {
+			t.Errorf("expected a location for %v.Area", impl.Key.Name)
+		}
+	}
+
+	sort.Strings(impls)
+	if len(impls) != 2 || impls[0] != "Rect" || impls[1] != "Square" {
+		t.Errorf("expected = %v, got = %v", []string{"Rect", "Square"}, impls)
+	}
+
+	line, _ := idx.lookup(typeKey{Pkg: "gno.land/p/demo/shapes", Name: "Line"})
+	for _, iface := range idx.interfacesOf(line) {
+		if iface.Key == shape.Key {
+			t.Errorf("expected Line not to implement Shape")
+		}
+	}
+}
+
+func TestTypeIndexStdlib(t *testing.T) {
+	idx := buildTypeIndex(nil)
+
+	iface, found := idx.lookup(typeKey{Pkg: "gno.land/p/demo/grc/grc20", Name: "IGRC20"})
+	if !found || !iface.Iface || len(iface.Methods) == 0 {
+		t.Fatalf("expected = %v, got = %v", "IGRC20", iface)
+	}
+
+	impls := map[typeKey]bool{}
+	for _, impl := range idx.implementations(iface) {
+		impls[impl.Key] = true
+	}
+
+	admin := typeKey{Pkg: "gno.land/p/demo/grc/grc20", Name: "AdminToken"}
+	if !impls[admin] {
+		t.Errorf("expected %v in %v", admin, impls)
+	}
+}
+
+func TestTypeIndexCache(t *testing.T) {
+	h := &handler{documents: store.NewDocumentStore(), rootDir: "../../testdata/implementation"}
+
+	first, err := h.typeIndex("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := h.typeIndex("")
+	if err != nil {
+		t.Fatal(err)
+	} else if first != second {
+		t.Error("expected the cached index to be reused")
+	}
+
+	h.types.invalidate()
+
+	third, err := h.typeIndex("")
+	if err != nil {
+		t.Fatal(err)
+	} else if third == first {
+		t.Error("expected a new index after a file changed")
+	}
+}
@@ -0,0 +1,448 @@
+package handler
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/stdlib"
+	"github.com/jdkato/gnols/internal/store"
+)
+
+var (
+	unusedImportRe = regexp.MustCompile(`^"([^"]+)" imported (?:as \w+ )?and not used`)
+	undefinedRe    = regexp.MustCompile(`^undefined: (\w+)$`)
+)
+
+// An importGroup is a section of an organized import block. Groups are
+// separated by a blank line.
+type importGroup int
+
+const (
+	importGroupStd   importGroup = iota // `std`, `strings`, ...
+	importGroupPure                     // `gno.land/p/...`
+	importGroupRealm                    // `gno.land/r/...`
+	importGroupOther
+)
+
+func groupOf(path string) importGroup {
+	switch {
+	case !strings.Contains(path, "."):
+		return importGroupStd
+	case strings.HasPrefix(path, "gno.land/p/"):
+		return importGroupPure
+	case strings.HasPrefix(path, "gno.land/r/"):
+		return importGroupRealm
+	default:
+		return importGroupOther
+	}
+}
+
+// importLine is a single import, as written in an organized block.
+type importLine struct {
+	Name    string // explicit name, if any
+	Path    string
+	Doc     string // the comment lines above the import, if any
+	Comment string // trailing comment, if any
+}
+
+// pkgName returns the name an import path is referred to by, by default.
+func pkgName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// usedPackageNames returns the names used as the package of a selector
+// expression (`avl` in `avl.NewTree()`) that don't refer to a local
+// declaration.
+func usedPackageNames(doc *store.Document) map[string]bool {
+	used := map[string]bool{}
+	ti := doc.TypeInfo()
+
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		x, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		// Failed imports (such as `std`) aren't recorded, so anything but a
+		// local object might be a package.
+		if ti != nil {
+			if obj := ti.Info.Uses[x]; obj != nil {
+				if _, isPkg := obj.(*types.PkgName); !isPkg {
+					return true
+				}
+			}
+		}
+
+		used[x.Name] = true
+		return true
+	})
+
+	return used
+}
+
+// missingImport finds the import path for an undefined package name, using
+// the symbol index. `sym`, if given, must be a member of the package.
+func missingImport(name, sym string) (string, bool) {
+	candidates := []string{}
+	for _, p := range stdlib.Packages {
+		if p.Name != name && pkgName(p.ImportPath) != name {
+			continue
+		}
+
+		if sym == "" {
+			candidates = append(candidates, p.ImportPath)
+			continue
+		}
+
+		for _, s := range p.Symbols {
+			if s.Name == sym {
+				candidates = append(candidates, p.ImportPath)
+				break
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	// Prefer the standard library, then the shortest path.
+	sort.Slice(candidates, func(i, j int) bool {
+		gi, gj := groupOf(candidates[i]), groupOf(candidates[j])
+		if gi != gj {
+			return gi < gj
+		} else if len(candidates[i]) != len(candidates[j]) {
+			return len(candidates[i]) < len(candidates[j])
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	return candidates[0], true
+}
+
+// undefinedPackages returns the package names used in the document that
+// aren't imported, along with one of their members.
+func undefinedPackages(doc *store.Document, used map[string]bool) map[string]string {
+	imported := map[string]bool{}
+	for _, spec := range doc.Pgf.File.Imports {
+		imported[importName(spec)] = true
+	}
+
+	undefined := map[string]string{}
+	ti := doc.TypeInfo()
+
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || imported[x.Name] || !used[x.Name] {
+			return true
+		}
+
+		// A local object shadows the package, if any.
+		if ti != nil && ti.ObjectOf(x) != nil {
+			return true
+		}
+
+		if _, found := undefined[x.Name]; !found {
+			undefined[x.Name] = sel.Sel.Name
+		}
+		return true
+	})
+
+	return undefined
+}
+
+// importName returns the name the imported package is referred to by.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	return pkgName(path)
+}
+
+// organizeImports returns the edits that sort and group the document's
+// imports, drop the unused ones and add the missing ones.
+func organizeImports(doc *store.Document) []protocol.TextEdit {
+	if !importsEditable(doc) {
+		return nil
+	}
+	used := usedPackageNames(doc)
+
+	lines := []importLine{}
+	for _, spec := range doc.Pgf.File.Imports {
+		name := importName(spec)
+		if name == "_" || name == "." || used[name] {
+			lines = append(lines, specLine(spec))
+		}
+	}
+
+	for name, sym := range undefinedPackages(doc, used) {
+		if path, found := missingImport(name, sym); found {
+			lines = append(lines, importLine{Path: path})
+		}
+	}
+
+	return replaceImports(doc, lines)
+}
+
+// addImport returns the edits that add the given import paths, keeping the
+// existing imports.
+func addImport(doc *store.Document, paths ...string) []protocol.TextEdit {
+	if !importsEditable(doc) {
+		return nil
+	}
+
+	lines := []importLine{}
+	imported := map[string]bool{}
+	for _, spec := range doc.Pgf.File.Imports {
+		line := specLine(spec)
+		lines = append(lines, line)
+		imported[line.Path] = true
+	}
+
+	for _, path := range paths {
+		if !imported[path] {
+			lines = append(lines, importLine{Path: path})
+		}
+	}
+	return replaceImports(doc, lines)
+}
+
+// removeImport returns the edits that remove the import of the given path.
+func removeImport(doc *store.Document, path string) []protocol.TextEdit {
+	if !importsEditable(doc) {
+		return nil
+	}
+
+	for _, decl := range doc.Pgf.File.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			is, isImport := spec.(*ast.ImportSpec)
+			if !isImport || is.Path.Value != strconv.Quote(path) {
+				continue
+			}
+
+			// The import's doc comment goes with it.
+			var first, node ast.Node = is, is
+			if len(gen.Specs) == 1 {
+				first, node = gen, gen
+				if gen.Doc != nil {
+					first = gen.Doc
+				}
+			} else if is.Doc != nil {
+				first = is.Doc
+			}
+			return []protocol.TextEdit{{Range: lineRange(doc, first, node), NewText: ""}}
+		}
+	}
+	return nil
+}
+
+// importsEditable reports whether the document's AST is complete and
+// matches its content, since the import edits rewrite whole declarations
+// (which a partial AST might be missing).
+func importsEditable(doc *store.Document) bool {
+	if doc.Pgf == nil || doc.Pgf.File == nil || doc.Pgf.Err != nil {
+		return false
+	}
+
+	tf := doc.Pgf.FileSet.File(doc.Pgf.File.Pos())
+	return tf != nil && tf.Size() == len(doc.Content)
+}
+
+// specLine returns the import line of a spec, along with its comments.
+func specLine(spec *ast.ImportSpec) importLine {
+	line := importLine{}
+	line.Path, _ = strconv.Unquote(spec.Path.Value)
+	if spec.Name != nil {
+		line.Name = spec.Name.Name
+	}
+	if spec.Doc != nil {
+		line.Doc = commentText(spec.Doc, "\n")
+	}
+	if spec.Comment != nil {
+		line.Comment = commentText(spec.Comment, " ")
+	}
+	return line
+}
+
+// commentText returns the source text of a comment group, joining its
+// comments with the given separator.
+func commentText(group *ast.CommentGroup, sep string) string {
+	texts := make([]string, 0, len(group.List))
+	for _, c := range group.List {
+		texts = append(texts, strings.TrimSpace(c.Text))
+	}
+	return strings.Join(texts, sep)
+}
+
+// floatingComments returns the comments between the first and last import
+// declarations that don't belong to an import (e.g., a comment followed by
+// a blank line), so that rewriting the declarations doesn't drop them.
+func floatingComments(file *ast.File, first, last ast.Node) []string {
+	attached := map[*ast.CommentGroup]bool{}
+	for _, spec := range file.Imports {
+		attached[spec.Doc] = true
+		attached[spec.Comment] = true
+	}
+
+	comments := []string{}
+	for _, group := range file.Comments {
+		if group.Pos() > first.Pos() && group.End() < last.End() && !attached[group] {
+			comments = append(comments, commentText(group, "\n"))
+		}
+	}
+	return comments
+}
+
+// replaceImports returns the edits that replace the document's import
+// declarations with an organized block of the given imports. It returns no
+// edits if the block wouldn't change.
+func replaceImports(doc *store.Document, lines []importLine) []protocol.TextEdit {
+	file := doc.Pgf.File
+
+	var first, last *ast.GenDecl
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			if first == nil {
+				first = gen
+			}
+			last = gen
+		}
+	}
+
+	if first == nil {
+		text := formatImports(lines, nil)
+		if text == "" {
+			return nil
+		}
+
+		end := doc.PosToPosition(file.Name.End())
+		return []protocol.TextEdit{{
+			Range:   protocol.Range{Start: end, End: end},
+			NewText: "\n\n" + text,
+		}}
+	}
+
+	text := formatImports(lines, floatingComments(file, first, last))
+	rng := protocol.Range{
+		Start: doc.PosToPosition(first.Pos()),
+		End:   doc.PosToPosition(last.End()),
+	}
+	if text == "" {
+		rng = lineRange(doc, first, last)
+	}
+
+	start := doc.Pgf.FileSet.Position(first.Pos()).Offset
+	end := doc.Pgf.FileSet.Position(last.End()).Offset
+	if start > end || end > len(doc.Content) {
+		return nil
+	} else if doc.Content[start:end] == text {
+		return nil
+	}
+
+	return []protocol.TextEdit{{Range: rng, NewText: text}}
+}
+
+// formatImports formats an import declaration, grouping and sorting the
+// imports. The given comments are kept at the top of the block.
+func formatImports(lines []importLine, comments []string) string {
+	seen := map[importLine]bool{}
+	unique := []importLine{}
+	for _, line := range lines {
+		if !seen[line] {
+			seen[line] = true
+			unique = append(unique, line)
+		}
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		gi, gj := groupOf(unique[i].Path), groupOf(unique[j].Path)
+		if gi != gj {
+			return gi < gj
+		}
+		return unique[i].Path < unique[j].Path
+	})
+
+	if len(unique) == 0 && len(comments) == 0 {
+		return ""
+	} else if len(unique) == 1 && len(comments) == 0 && unique[0].Doc == "" {
+		return "import " + unique[0].String()
+	}
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, c := range comments {
+		b.WriteString(indentLines(c) + "\n\n")
+	}
+	for i, line := range unique {
+		if i > 0 && groupOf(line.Path) != groupOf(unique[i-1].Path) {
+			b.WriteString("\n")
+		}
+		if line.Doc != "" {
+			b.WriteString(indentLines(line.Doc) + "\n")
+		}
+		b.WriteString("\t" + line.String() + "\n")
+	}
+	b.WriteString(")")
+
+	return b.String()
+}
+
+// indentLines indents each line of a comment by a tab.
+func indentLines(text string) string {
+	return "\t" + strings.ReplaceAll(text, "\n", "\n\t")
+}
+
+func (l importLine) String() string {
+	s := strconv.Quote(l.Path)
+	if l.Name != "" {
+		s = l.Name + " " + s
+	}
+	if l.Comment != "" {
+		s += " " + l.Comment
+	}
+	return s
+}
+
+// lineRange returns the range of the full lines spanned by the given nodes,
+// including a following blank line (so that removing them doesn't leave a
+// gap).
+func lineRange(doc *store.Document, first, last ast.Node) protocol.Range {
+	start := doc.PosToPosition(first.Pos()).Line
+	end := doc.PosToPosition(last.End()).Line + 1
+
+	if int(end) < len(doc.Lines) && strings.TrimSpace(doc.Lines[end]) == "" {
+		end++
+	}
+
+	return protocol.Range{
+		Start: protocol.Position{Line: start},
+		End:   protocol.Position{Line: end},
+	}
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/gno"
+)
+
+func TestOrganizeImports(t *testing.T) {
+	doc := loadTestDoc(t, "imports/unused.gno")
+
+	edits := organizeImports(doc)
+	if len(edits) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, len(edits))
+	}
+
+	expected := `import (
+	"std"
+	"strings"
+
+	"gno.land/p/demo/avl"
+	"gno.land/p/demo/ufmt"
+)`
+	if edits[0].NewText != expected {
+		t.Errorf("expected = %v, got = %v", expected, edits[0].NewText)
+	}
+
+	rng := edits[0].Range
+	if rng.Start.Line != 2 || rng.End.Line != 7 {
+		t.Errorf("expected = %v, got = %v", "2-7", rng)
+	}
+}
+
+func TestImportFixes(t *testing.T) {
+	doc := loadTestDoc(t, "imports/unused.gno")
+
+	actions := importFixes(doc, protocol.Diagnostic{
+		Message: `"gno.land/r/demo/users" imported and not used`,
+	})
+	if len(actions) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, len(actions))
+	}
+
+	edit := actions[0].Edits[0]
+	if edit.NewText != "" || edit.Range.Start.Line != 3 || edit.Range.End.Line != 4 {
+		t.Errorf("expected = %v, got = %v", "delete line 3", edit)
+	}
+
+	actions = importFixes(doc, protocol.Diagnostic{Message: "undefined: ufmt"})
+	if len(actions) != 1 || actions[0].Title != `Add import "gno.land/p/demo/ufmt"` {
+		t.Errorf("expected = %v, got = %v", `Add import "gno.land/p/demo/ufmt"`, actions)
+	}
+}
+
+func TestWantsKind(t *testing.T) {
+	only := []protocol.CodeActionKind{protocol.Source}
+	if !wantsKind(only, protocol.SourceOrganizeImports) {
+		t.Errorf("expected = %v, got = %v", true, false)
+	}
+	if wantsKind(only, protocol.QuickFix) {
+		t.Errorf("expected = %v, got = %v", false, true)
+	}
+}
+
+func TestSaveEdits(t *testing.T) {
+	doc := loadTestDoc(t, "imports/unused.gno")
+
+	mgr, err := gno.NewBinManager("gno", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &handler{binManager: mgr, organizeOnSave: true}
+
+	saved := applyEdits(doc.Content, h.saveEdits(doc))
+
+	expected, err := format.Source([]byte(applyEdits(doc.Content, organizeImports(doc))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saved != string(expected) {
+		t.Errorf("expected = %v, got = %v", string(expected), saved)
+	}
+
+	if strings.Contains(saved, "gno.land/r/demo/users") || !strings.Contains(saved, "gno.land/p/demo/ufmt") {
+		t.Errorf("expected = %v, got = %v", "organized imports", saved)
+	}
+}
+
+func TestSaveEditsKeepImports(t *testing.T) {
+	doc := loadTestDoc(t, "imports/unused.gno")
+
+	mgr, err := gno.NewBinManager("gno", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &handler{binManager: mgr}
+
+	// Imports are only organized on save if the user asks for it.
+	saved := applyEdits(doc.Content, h.saveEdits(doc))
+
+	expected, err := format.Source([]byte(doc.Content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saved != string(expected) {
+		t.Errorf("expected = %v, got = %v", string(expected), saved)
+	}
+}
+
+func TestSaveEditsUnparsed(t *testing.T) {
+	h := &handler{}
+
+	doc := loadTestDoc(t, "imports/unused.gno")
+	doc.ApplyChanges([]protocol.TextDocumentContentChangeEvent{
+		{Text: "package demo\n\nimport (\n\t\"std\"\n\nfunc Render("},
+	})
+	if edits := h.saveEdits(doc); len(edits) != 0 {
+		t.Errorf("expected = %v, got = %v", 0, edits)
+	}
+
+	// An AST that doesn't match the content (e.g., the last one that
+	// parsed) must not be used to edit it either.
+	stale := loadTestDoc(t, "imports/unused.gno")
+	stale.Content = "package demo\n\nfunc {\n"
+	stale.Lines = strings.SplitAfter(stale.Content, "\n")
+	if edits := h.saveEdits(stale); len(edits) != 0 {
+		t.Errorf("expected = %v, got = %v", 0, edits)
+	}
+}
+
+func TestImportsUnparsed(t *testing.T) {
+	doc := loadTestDoc(t, "imports/unused.gno")
+	doc.ApplyChanges([]protocol.TextDocumentContentChangeEvent{
+		{Text: "package demo\n\nfunc {\n"},
+	})
+
+	if edits := organizeImports(doc); len(edits) != 0 {
+		t.Errorf("expected = %v, got = %v", 0, edits)
+	}
+	if edits := addImport(doc, "std"); len(edits) != 0 {
+		t.Errorf("expected = %v, got = %v", 0, edits)
+	}
+	if edits := removeImport(doc, "strings"); len(edits) != 0 {
+		t.Errorf("expected = %v, got = %v", 0, edits)
+	}
+}
+
+func TestOrganizeImportsComments(t *testing.T) {
+	doc := loadTestDoc(t, "imports/comments.gno")
+
+	edits := organizeImports(doc)
+	if len(edits) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, len(edits))
+	}
+
+	expected := `import (
+	/* and more */
+
+	// Unattached note.
+
+	// trailing note
+
+	// More imports.
+
+	"std" // for the caller
+	// Keep strings for ToUpper.
+	"strings" // upper-casing
+
+	// Pure packages.
+	"gno.land/p/demo/avl"
+)`
+	if edits[0].NewText != expected {
+		t.Errorf("expected = %v, got = %v", expected, edits[0].NewText)
+	}
+
+	organized := applyEdits(doc.Content, edits)
+	if !strings.Contains(organized, "// The imports.\nimport (") {
+		t.Errorf("expected = %v, got = %v", "the block's doc comment", organized)
+	}
+
+	if _, err := format.Source([]byte(organized)); err != nil {
+		t.Errorf("expected = %v, got = %v", nil, err)
+	}
+}
+
+func TestRemoveImportDoc(t *testing.T) {
+	doc := loadTestDoc(t, "imports/comments.gno")
+
+	edits := removeImport(doc, "gno.land/p/demo/avl")
+	if len(edits) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, len(edits))
+	}
+
+	removed := applyEdits(doc.Content, edits)
+	if strings.Contains(removed, "Pure packages") || !strings.Contains(removed, "/* and more */\n\n\t// Unattached") {
+		t.Errorf("expected = %v, got = %v", "the import and its doc removed", removed)
+	}
+}
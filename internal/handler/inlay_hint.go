@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// NOTE: Inlay hints were added in LSP 3.17, so they're not part of
+// `go.lsp.dev/protocol`.
+const methodTextDocumentInlayHint = "textDocument/inlayHint"
+
+type inlayHintKind int
+
+const (
+	inlayHintKindType      inlayHintKind = 1
+	inlayHintKindParameter inlayHintKind = 2
+)
+
+type inlayHintParams struct {
+	TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+	Range        protocol.Range                  `json:"range"`
+}
+
+type inlayHint struct {
+	Position     protocol.Position `json:"position"`
+	Label        string            `json:"label"`
+	Kind         inlayHintKind     `json:"kind,omitempty"`
+	PaddingLeft  bool              `json:"paddingLeft,omitempty"`
+	PaddingRight bool              `json:"paddingRight,omitempty"`
+}
+
+// hintSettings controls which kinds of inlay hints are shown.
+type hintSettings struct {
+	ParameterNames      bool // `Sprintf(format: "%s", ...)`
+	AssignVariableTypes bool // `x int := 1`
+	RangeVariableTypes  bool // `for k string, v int := range m`
+}
+
+func (h *handler) handleInlayHint(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params inlayHintParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	hints := []inlayHint{}
+	if doc.Pgf == nil || doc.Pgf.FileSet == nil {
+		return reply(ctx, hints, nil)
+	}
+
+	for _, hint := range inlayHints(doc, h.hints) {
+		line := hint.Position.Line
+		if line >= params.Range.Start.Line && line <= params.Range.End.Line {
+			hints = append(hints, hint)
+		}
+	}
+
+	return reply(ctx, hints, nil)
+}
+
+// inlayHints computes all of the enabled hints for the document.
+func inlayHints(doc *store.Document, settings hintSettings) []inlayHint {
+	hints := []inlayHint{}
+	ti := doc.TypeInfo()
+
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			if settings.ParameterNames {
+				hints = append(hints, parameterHints(doc, ti, node)...)
+			}
+		case *ast.AssignStmt:
+			if settings.AssignVariableTypes && node.Tok == token.DEFINE {
+				hints = append(hints, assignHints(doc, ti, node)...)
+			}
+		case *ast.RangeStmt:
+			if settings.RangeVariableTypes && node.Tok == token.DEFINE {
+				for _, expr := range []ast.Expr{node.Key, node.Value} {
+					if id, ok := expr.(*ast.Ident); ok {
+						hints = append(hints, typeHints(doc, ti, id)...)
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	return hints
+}
+
+// parameterHints labels the literal arguments of a call with the names of
+// their parameters.
+func parameterHints(doc *store.Document, ti *store.TypeInfo, call *ast.CallExpr) []inlayHint {
+	hints := []inlayHint{}
+
+	names := paramNames(doc, ti, call.Fun)
+	if len(names) == 0 {
+		return hints
+	}
+
+	for i, arg := range call.Args {
+		if !isLiteral(arg) {
+			continue
+		}
+
+		name := names[len(names)-1] // variadic
+		if i < len(names) {
+			name = names[i]
+		}
+
+		if name == "" || name == "_" {
+			continue
+		}
+
+		hints = append(hints, inlayHint{
+			Position:     doc.PosToPosition(arg.Pos()),
+			Label:        name + ":",
+			Kind:         inlayHintKindParameter,
+			PaddingRight: true,
+		})
+	}
+
+	return hints
+}
+
+// paramNames returns the parameter names of the called function, using the
+// type checker if it knows about the function and our symbol index
+// otherwise.
+func paramNames(doc *store.Document, ti *store.TypeInfo, fun ast.Expr) []string {
+	names := []string{}
+
+	if ti != nil {
+		if sig, ok := ti.Info.TypeOf(fun).(*types.Signature); ok {
+			for i := 0; i < sig.Params().Len(); i++ {
+				names = append(names, sig.Params().At(i).Name())
+			}
+			return names
+		}
+	}
+
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return names
+	}
+
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return names
+	}
+
+	sym := lookupSymbolByImports(sel.Sel.Name, importsNamed(doc.Pgf.File, x.Name))
+	if sym == nil || sym.Kind != "func" {
+		return names
+	}
+
+	fn := parseSignature(sym.Signature)
+	if fn == nil || fn.Recv != nil {
+		return names
+	}
+
+	for _, field := range fn.Type.Params.List {
+		if len(field.Names) == 0 {
+			names = append(names, "")
+		}
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+
+	return names
+}
+
+// isLiteral reports whether the expression is a literal value, where the
+// parameter name adds information.
+func isLiteral(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit, *ast.CompositeLit:
+		return true
+	case *ast.UnaryExpr:
+		return isLiteral(e.X)
+	case *ast.Ident:
+		return e.Name == "true" || e.Name == "false" || e.Name == "nil"
+	default:
+		return false
+	}
+}
+
+// assignHints shows the inferred types of the variables declared by a `:=`
+// statement.
+//
+// If the type checker doesn't know a type, and the value comes from a call to
+// an indexed function (e.g., `tree := avl.NewTree()`), we use the
+// function's signature instead.
+func assignHints(doc *store.Document, ti *store.TypeInfo, assign *ast.AssignStmt) []inlayHint {
+	hints := []inlayHint{}
+
+	var results []string
+	if len(assign.Rhs) == 1 {
+		if call, ok := assign.Rhs[0].(*ast.CallExpr); ok {
+			results = indexedResults(doc, call)
+		}
+	}
+
+	for i, lhs := range assign.Lhs {
+		id, ok := lhs.(*ast.Ident)
+		if !ok || id.Name == "_" {
+			continue
+		}
+
+		found := typeHints(doc, ti, id)
+		if len(found) == 0 && len(results) == len(assign.Lhs) {
+			found = []inlayHint{{
+				Position:    doc.PosToPosition(id.End()),
+				Label:       results[i],
+				Kind:        inlayHintKindType,
+				PaddingLeft: true,
+			}}
+		}
+
+		hints = append(hints, found...)
+	}
+
+	return hints
+}
+
+// indexedResults returns the (package-qualified) result types of a call to a
+// function in our symbol index.
+func indexedResults(doc *store.Document, call *ast.CallExpr) []string {
+	results := []string{}
+	for _, expr := range indexedResultTypes(doc.Pgf.File, call) {
+		results = append(results, exprString(expr))
+	}
+	return results
+}
+
+// indexedResultTypes returns the result type expressions of a call to a
+// function in our symbol index, qualified by the package name used in the
+// calling file.
+func indexedResultTypes(file *ast.File, call *ast.CallExpr) []ast.Expr {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+
+	sym := lookupSymbolByImports(sel.Sel.Name, importsNamed(file, x.Name))
+	if sym == nil || sym.Kind != "func" {
+		return nil
+	}
+
+	fn := parseSignature(sym.Signature)
+	if fn == nil || fn.Recv != nil || fn.Type.Results == nil {
+		return nil
+	}
+
+	results := []ast.Expr{}
+	for _, field := range fn.Type.Results.List {
+		qualifyExpr(field.Type, x.Name)
+
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for j := 0; j < n; j++ {
+			results = append(results, field.Type)
+		}
+	}
+
+	return results
+}
+
+// qualifyExpr qualifies the exported names of a type expression taken from
+// the given package's source (`Coins` becomes `std.Coins`).
+//
+// NOTE: This modifies the expression, which must not be shared.
+func qualifyExpr(expr ast.Expr, pkg string) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			return false
+		case *ast.Ident:
+			if node.IsExported() {
+				node.Name = pkg + "." + node.Name
+			}
+		}
+		return true
+	})
+}
+
+// typeHints shows the inferred type of a newly-declared variable.
+func typeHints(doc *store.Document, ti *store.TypeInfo, id *ast.Ident) []inlayHint {
+	if ti == nil || id.Name == "_" {
+		return nil
+	}
+
+	obj := ti.Info.Defs[id]
+	if obj == nil || obj.Type() == nil {
+		return nil
+	}
+
+	typeName := types.TypeString(obj.Type(), types.RelativeTo(ti.Pkg))
+	if strings.Contains(typeName, "invalid type") {
+		// This is usually a type from a Gno-only package (e.g.,
+		// `std.Coins`), which the type checker doesn't know about.
+		return nil
+	}
+
+	return []inlayHint{{
+		Position:    doc.PosToPosition(id.End()),
+		Label:       typeName,
+		Kind:        inlayHintKindType,
+		PaddingLeft: true,
+	}}
+}
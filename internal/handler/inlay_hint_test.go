@@ -0,0 +1,40 @@
+package handler
+
+import "testing"
+
+func TestInlayHints(t *testing.T) {
+	doc := loadTestDoc(t, "inlay_hint/hints.gno")
+
+	hints := inlayHints(doc, hintSettings{
+		ParameterNames:      true,
+		AssignVariableTypes: true,
+		RangeVariableTypes:  true,
+	})
+
+	type key struct {
+		label string
+		line  uint32
+	}
+	expected := []key{
+		{"*avl.Tree", 8},
+		{"int", 9},
+		{"int", 10},
+		{"rune", 10},
+		{"format:", 13},
+	}
+
+	found := map[key]bool{}
+	for _, hint := range hints {
+		found[key{hint.Label, hint.Position.Line}] = true
+	}
+
+	for _, k := range expected {
+		if !found[k] {
+			t.Errorf("expected a %q hint on line %d, got = %v", k.label, k.line, hints)
+		}
+	}
+
+	if len(inlayHints(doc, hintSettings{})) != 0 {
+		t.Error("expected no hints when disabled")
+	}
+}
@@ -0,0 +1,489 @@
+package handler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+var (
+	unusedVarRe   = regexp.MustCompile(`^(?:declared and not used: (\w+)|(\w+) declared (?:and|but) not used)$`)
+	cannotUseRe   = regexp.MustCompile(`^cannot use (.+) \([^()]*type ([\w\[\]]+)\) as ([\w\[\]]+) value`)
+	mismatchedRe  = regexp.MustCompile(`^invalid operation: (.+) \(mismatched types ([\w\[\]]+) and ([\w\[\]]+)\)$`)
+	missingReturn = "missing return"
+)
+
+// A quickFix is a fix for a single diagnostic.
+//
+// Fixes are computed along with the diagnostics and stored in their data
+// field, so that clients can apply them directly. They're only valid for the
+// content they were computed from, so code actions recompute them.
+type quickFix struct {
+	Title     string              `json:"title"`
+	Edits     []protocol.TextEdit `json:"edits"`
+	Preferred bool                `json:"preferred,omitempty"`
+}
+
+type diagnosticData struct {
+	Fixes []quickFix `json:"fixes"`
+}
+
+// fixers compute the quick fixes for a diagnostic; each one handles a
+// single kind of error, ignoring the others.
+var fixers = []func(*store.Document, protocol.Diagnostic) []quickFix{
+	importFixes,
+	undefinedFixes,
+	missingReturnFixes,
+	unusedVarFixes,
+	conversionFixes,
+}
+
+// diagnosticFixes computes all of the quick fixes for a diagnostic.
+func diagnosticFixes(doc *store.Document, diag protocol.Diagnostic) []quickFix {
+	fixes := []quickFix{}
+	if doc.Pgf == nil || doc.Pgf.File == nil {
+		return fixes
+	}
+
+	for _, fixer := range fixers {
+		fixes = append(fixes, fixer(doc, diag)...)
+	}
+	return fixes
+}
+
+// fixActions returns the code actions for a diagnostic's quick fixes.
+//
+// The fixes are always computed from the current content: the ones stored
+// in the diagnostic's data field may be for an older version of the
+// document, so their offsets can't be trusted.
+func fixActions(doc *store.Document, diag protocol.Diagnostic) []protocol.CodeAction {
+	actions := []protocol.CodeAction{}
+	for _, fix := range diagnosticFixes(doc, diag) {
+		actions = append(actions, protocol.CodeAction{
+			Title:       fix.Title,
+			Kind:        protocol.QuickFix,
+			Diagnostics: []protocol.Diagnostic{diag},
+			IsPreferred: fix.Preferred,
+			Edit:        docEdit(doc, fix.Edits),
+		})
+	}
+	return actions
+}
+
+// importFixes removes unused imports and adds missing ones.
+func importFixes(doc *store.Document, diag protocol.Diagnostic) []quickFix {
+	var title string
+	var edits []protocol.TextEdit
+
+	if m := unusedImportRe.FindStringSubmatch(diag.Message); m != nil {
+		title = fmt.Sprintf("Remove unused import %q", m[1])
+		edits = removeImport(doc, m[1])
+	} else if m := undefinedRe.FindStringSubmatch(diag.Message); m != nil {
+		sym, found := undefinedPackages(doc, usedPackageNames(doc))[m[1]]
+		if !found {
+			return nil
+		}
+
+		path, found := missingImport(m[1], sym)
+		if !found {
+			return nil
+		}
+
+		title = fmt.Sprintf("Add import %q", path)
+		edits = addImport(doc, path)
+	}
+
+	if len(edits) == 0 {
+		return nil
+	}
+	return []quickFix{{Title: title, Edits: edits, Preferred: true}}
+}
+
+// undefinedFixes suggests near-miss identifiers for an undefined name (so
+// `strin` becomes `string`).
+func undefinedFixes(doc *store.Document, diag protocol.Diagnostic) []quickFix {
+	m := undefinedRe.FindStringSubmatch(diag.Message)
+	if m == nil {
+		return nil
+	}
+
+	id := identOnLine(doc, diag.Range.Start, m[1])
+	if id == nil {
+		return nil
+	}
+
+	fixes := []quickFix{}
+	for i, name := range nearMisses(m[1], namesInScope(doc, id.Pos())) {
+		fixes = append(fixes, quickFix{
+			Title:     fmt.Sprintf("Change %q to %q", m[1], name),
+			Edits:     []protocol.TextEdit{{Range: doc.NodeRange(id), NewText: name}},
+			Preferred: i == 0,
+		})
+	}
+	return fixes
+}
+
+// namesInScope returns the names visible at the given position, including
+// the predeclared ones.
+func namesInScope(doc *store.Document, pos token.Pos) []string {
+	scope := types.Universe
+	if ti := doc.TypeInfo(); ti != nil && ti.Pkg != nil {
+		if inner := ti.Pkg.Scope().Innermost(pos); inner != nil {
+			scope = inner
+		}
+	}
+
+	names := []string{}
+	for ; scope != nil; scope = scope.Parent() {
+		names = append(names, scope.Names()...)
+	}
+	return names
+}
+
+// nearMisses returns (up to three of) the candidates that are within a small
+// edit distance of the name, closest first.
+func nearMisses(name string, candidates []string) []string {
+	limit := len(name) / 3
+	if limit < 1 {
+		limit = 1
+	}
+
+	dist := map[string]int{}
+	for _, c := range candidates {
+		if d := editDistance(name, c); c != name && d <= limit {
+			dist[c] = d
+		}
+	}
+
+	found := make([]string, 0, len(dist))
+	for c := range dist {
+		found = append(found, c)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if dist[found[i]] != dist[found[j]] {
+			return dist[found[i]] < dist[found[j]]
+		}
+		return found[i] < found[j]
+	})
+
+	if len(found) > 3 {
+		found = found[:3]
+	}
+	return found
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+
+	return prev[len(b)]
+}
+
+// missingReturnFixes adds a `return` of zero values at the end of the
+// function.
+func missingReturnFixes(doc *store.Document, diag protocol.Diagnostic) []quickFix {
+	if diag.Message != missingReturn {
+		return nil
+	}
+
+	var fn *ast.FuncType
+	var body *ast.BlockStmt
+	for _, n := range enclosingNodes(doc.Pgf.File, doc.PositionToPos(diag.Range.Start)) {
+		switch node := n.(type) {
+		case *ast.FuncDecl:
+			fn, body = node.Type, node.Body
+		case *ast.FuncLit:
+			fn, body = node.Type, node.Body
+		}
+	}
+
+	if fn == nil || body == nil || fn.Results == nil {
+		return nil
+	}
+
+	zeros := []string{}
+	for _, field := range fn.Results.List {
+		if len(field.Names) > 0 {
+			zeros = nil // named results: a bare return works
+			break
+		}
+		zeros = append(zeros, zeroValue(field.Type))
+	}
+
+	// We only handle the usual layout, where the closing brace is on its
+	// own line.
+	end := doc.PosToPosition(body.Rbrace)
+	line := doc.Lines[end.Line]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	if !strings.HasPrefix(line[len(indent):], "}") {
+		return nil
+	}
+
+	stmt := strings.TrimSpace("return " + strings.Join(zeros, ", "))
+	start := protocol.Position{Line: end.Line}
+
+	return []quickFix{{
+		Title:     "Add return statement",
+		Edits:     []protocol.TextEdit{{Range: protocol.Range{Start: start, End: start}, NewText: indent + "\t" + stmt + "\n"}},
+		Preferred: true,
+	}}
+}
+
+// unusedVarFixes removes an unused variable or renames it to `_`.
+func unusedVarFixes(doc *store.Document, diag protocol.Diagnostic) []quickFix {
+	m := unusedVarRe.FindStringSubmatch(diag.Message)
+	if m == nil {
+		return nil
+	}
+
+	name := m[1] + m[2]
+	id := identOnLine(doc, diag.Range.Start, name)
+	if id == nil {
+		return nil
+	}
+
+	nodes := enclosingNodes(doc.Pgf.File, id.Pos())
+	for i := len(nodes) - 1; i >= 0; i-- {
+		switch node := nodes[i].(type) {
+		case *ast.AssignStmt:
+			return assignFixes(doc, node, id)
+		case *ast.RangeStmt:
+			return rangeFixes(doc, node, id)
+		case *ast.ValueSpec:
+			fixes := []quickFix{renameFix(doc, id)}
+			if decl, ok := nodes[max(i-1, 0)].(*ast.GenDecl); ok && len(decl.Specs) == 1 &&
+				len(node.Names) == 1 && !hasCall(node.Values...) {
+				fixes = append(fixes, removeFix(doc, decl, name))
+			}
+			return fixes
+		}
+	}
+
+	return nil
+}
+
+func assignFixes(doc *store.Document, assign *ast.AssignStmt, id *ast.Ident) []quickFix {
+	rename := renameFix(doc, id)
+
+	// `_ := f()` isn't valid if there are no other new variables.
+	others := false
+	ti := doc.TypeInfo()
+	for _, lhs := range assign.Lhs {
+		if other, ok := lhs.(*ast.Ident); ok && other != id && other.Name != "_" {
+			others = others || ti == nil || ti.Info.Defs[other] != nil
+		}
+	}
+
+	if assign.Tok == token.DEFINE && !others {
+		rename.Edits = append(rename.Edits, protocol.TextEdit{
+			Range: protocol.Range{
+				Start: doc.PosToPosition(assign.TokPos),
+				End:   doc.PosToPosition(assign.TokPos + 2),
+			},
+			NewText: "=",
+		})
+	}
+
+	fixes := []quickFix{rename}
+	if len(assign.Lhs) == 1 && !hasCall(assign.Rhs...) {
+		fixes = append(fixes, removeFix(doc, assign, id.Name))
+	}
+	return fixes
+}
+
+func rangeFixes(doc *store.Document, stmt *ast.RangeStmt, id *ast.Ident) []quickFix {
+	switch {
+	case stmt.Value == id:
+		// `for k, v := range` -> `for k := range`
+		return []quickFix{{
+			Title: fmt.Sprintf("Remove unused variable %q", id.Name),
+			Edits: []protocol.TextEdit{{
+				Range: protocol.Range{
+					Start: doc.PosToPosition(stmt.Key.End()),
+					End:   doc.PosToPosition(stmt.Value.End()),
+				},
+				NewText: "",
+			}},
+			Preferred: true,
+		}}
+	case stmt.Key == id && stmt.Value == nil:
+		// `for k := range` -> `for range`
+		return []quickFix{{
+			Title: fmt.Sprintf("Remove unused variable %q", id.Name),
+			Edits: []protocol.TextEdit{{
+				Range: protocol.Range{
+					Start: doc.PosToPosition(stmt.Key.Pos()),
+					End:   doc.PosToPosition(stmt.TokPos + token.Pos(len(stmt.Tok.String())+1)),
+				},
+				NewText: "",
+			}},
+			Preferred: true,
+		}}
+	default:
+		return []quickFix{renameFix(doc, id)}
+	}
+}
+
+func renameFix(doc *store.Document, id *ast.Ident) quickFix {
+	return quickFix{
+		Title: fmt.Sprintf("Rename %q to %q", id.Name, "_"),
+		Edits: []protocol.TextEdit{{Range: doc.NodeRange(id), NewText: "_"}},
+	}
+}
+
+func removeFix(doc *store.Document, n ast.Node, name string) quickFix {
+	return quickFix{
+		Title:     fmt.Sprintf("Remove unused variable %q", name),
+		Edits:     []protocol.TextEdit{{Range: lineRange(doc, n, n), NewText: ""}},
+		Preferred: true,
+	}
+}
+
+// hasCall reports whether any of the expressions contains a call, which we
+// assume has side effects.
+func hasCall(exprs ...ast.Expr) bool {
+	found := false
+	for _, expr := range exprs {
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if _, ok := n.(*ast.CallExpr); ok {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// conversionFixes adds a conversion for mismatched numeric (or string)
+// types.
+func conversionFixes(doc *store.Document, diag protocol.Diagnostic) []quickFix {
+	var expr ast.Expr
+	var to string
+
+	if m := cannotUseRe.FindStringSubmatch(diag.Message); m != nil {
+		if !convertible(m[2], m[3]) {
+			return nil
+		}
+		expr, to = exprOnLine(doc, diag.Range.Start, m[1]), m[3]
+	} else if m := mismatchedRe.FindStringSubmatch(diag.Message); m != nil {
+		if !convertible(m[3], m[2]) {
+			return nil
+		}
+		if bin, ok := exprOnLine(doc, diag.Range.Start, m[1]).(*ast.BinaryExpr); ok {
+			expr, to = bin.Y, m[2]
+		}
+	}
+
+	if expr == nil {
+		return nil
+	}
+
+	text := nodeText(doc, expr)
+	return []quickFix{{
+		Title:     fmt.Sprintf("Convert %q to %s", text, to),
+		Edits:     []protocol.TextEdit{{Range: doc.NodeRange(expr), NewText: to + "(" + text + ")"}},
+		Preferred: true,
+	}}
+}
+
+// convertible reports whether a simple conversion fixes a mismatch between
+// the two types.
+func convertible(from, to string) bool {
+	numeric := func(name string) bool {
+		obj, ok := types.Universe.Lookup(name).(*types.TypeName)
+		if !ok {
+			return false
+		}
+		basic, isBasic := obj.Type().(*types.Basic)
+		return isBasic && basic.Info()&types.IsNumeric != 0
+	}
+
+	switch {
+	case numeric(from) && numeric(to):
+		return true
+	case from == "string":
+		return to == "[]byte" || to == "[]rune"
+	case to == "string":
+		return from == "[]byte" || from == "[]rune"
+	default:
+		return false
+	}
+}
+
+// identOnLine finds the identifier with the given name on the line of the
+// given position, preferring the closest one.
+func identOnLine(doc *store.Document, pos protocol.Position, name string) *ast.Ident {
+	var found *ast.Ident
+	best := -1
+
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id.Name != name {
+			return true
+		}
+
+		start := doc.PosToPosition(id.Pos())
+		if start.Line != pos.Line {
+			return true
+		}
+
+		d := int(start.Character) - int(pos.Character)
+		if d < 0 {
+			d = -d
+		}
+		if best < 0 || d < best {
+			found, best = id, d
+		}
+		return true
+	})
+
+	return found
+}
+
+// exprOnLine finds the expression with the given text on the line of the
+// given position.
+func exprOnLine(doc *store.Document, pos protocol.Position, text string) ast.Expr {
+	var found ast.Expr
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+
+		expr, ok := n.(ast.Expr)
+		if ok && doc.PosToPosition(expr.Pos()).Line == pos.Line && exprString(expr) == text {
+			found = expr
+		}
+		return true
+	})
+	return found
+}
+
+// nodeText returns the source text of the given node.
+func nodeText(doc *store.Document, n ast.Node) string {
+	return nodeTextRange(doc, n.Pos(), n.End())
+}
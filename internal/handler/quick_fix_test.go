@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestQuickFixes(t *testing.T) {
+	doc := loadTestDoc(t, "quick_fix/errors.gno")
+
+	cases := []struct {
+		msg   string
+		pos   protocol.Position
+		title string
+		text  string
+	}{
+		{"undefined: strin", protocol.Position{Line: 2, Character: 17}, `Change "strin" to "string"`, "string"},
+		{"missing return", protocol.Position{Line: 10, Character: 0}, "Add return statement", "\treturn 0\n"},
+		{"declared and not used: i", protocol.Position{Line: 14, Character: 5}, `Rename "i" to "_"`, "_"},
+		{"declared and not used: unused", protocol.Position{Line: 17, Character: 1}, `Rename "unused" to "_"`, "_"},
+		{
+			"invalid operation: total + v (mismatched types int64 and int)",
+			protocol.Position{Line: 15, Character: 10},
+			`Convert "v" to int64`,
+			"int64(v)",
+		},
+	}
+
+	for _, c := range cases {
+		fixes := diagnosticFixes(doc, protocol.Diagnostic{
+			Message: c.msg,
+			Range:   protocol.Range{Start: c.pos, End: c.pos},
+		})
+
+		if len(fixes) == 0 {
+			t.Errorf("%s: expected = %v, got = %v", c.msg, c.title, fixes)
+			continue
+		}
+
+		fix := fixes[0]
+		if fix.Title != c.title || fix.Edits[0].NewText != c.text {
+			t.Errorf("%s: expected = %v, got = %v", c.msg, c.title, fix)
+		}
+	}
+}
+
+func TestUnusedVarFixes(t *testing.T) {
+	doc := loadTestDoc(t, "quick_fix/errors.gno")
+
+	fixes := unusedVarFixes(doc, protocol.Diagnostic{
+		Message: "unused declared and not used",
+		Range:   protocol.Range{Start: protocol.Position{Line: 17, Character: 1}},
+	})
+
+	// Renaming the only new variable also turns `:=` into `=`.
+	if len(fixes) != 2 || len(fixes[0].Edits) != 2 || fixes[0].Edits[1].NewText != "=" {
+		t.Fatalf("expected = %v, got = %v", "rename + remove", fixes)
+	}
+
+	remove := fixes[1].Edits[0].Range
+	if remove.Start.Line != 17 || remove.End.Line != 18 {
+		t.Errorf("expected = %v, got = %v", "17-18", remove)
+	}
+}
+
+func TestFixActionsStaleData(t *testing.T) {
+	doc := loadTestDoc(t, "quick_fix/errors.gno")
+
+	// The stored fix was computed for an older version of the document.
+	actions := fixActions(doc, protocol.Diagnostic{
+		Message: "undefined: strin",
+		Range:   protocol.Range{Start: protocol.Position{Line: 2, Character: 17}},
+		Data: diagnosticData{Fixes: []quickFix{{
+			Title: "stale",
+			Edits: []protocol.TextEdit{{NewText: "string"}},
+		}}},
+	})
+
+	if len(actions) == 0 || actions[0].Title != `Change "strin" to "string"` {
+		t.Fatalf("expected = %v, got = %v", `Change "strin" to "string"`, actions)
+	}
+
+	edit := actions[0].Edit.Changes[doc.URI][0]
+	if edit.Range.Start.Line != 2 {
+		t.Errorf("expected = %v, got = %v", 2, edit.Range)
+	}
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// rewriteActions returns the `refactor.rewrite` actions at the given
+// position: filling an empty struct literal and implementing an interface.
+func (h *handler) rewriteActions(doc *store.Document, pos protocol.Position) []protocol.CodeAction {
+	actions := []protocol.CodeAction{}
+
+	pkg, err := h.documents.LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		return actions
+	}
+
+	if edits := fillStructEdits(doc, pkg, pos); len(edits) > 0 {
+		actions = append(actions, protocol.CodeAction{
+			Title: "Fill struct",
+			Kind:  protocol.RefactorRewrite,
+			Edit:  docEdit(doc, edits),
+		})
+	}
+
+	spec := typeSpecAt(doc, pos)
+	if spec == nil {
+		return actions
+	}
+
+	idx, err := h.typeIndex(doc.Path)
+	if err != nil {
+		return actions
+	}
+
+	for _, iface := range implementCandidates(doc, pkg, idx, spec) {
+		if edits := implementEdits(doc, pkg, idx, spec, iface); len(edits) > 0 {
+			actions = append(actions, protocol.CodeAction{
+				Title: "Implement " + ifaceName(doc, pkg, iface.Key),
+				Kind:  protocol.RefactorRewrite,
+				Edit:  docEdit(doc, edits),
+			})
+		}
+	}
+
+	return actions
+}
+
+// fillStructEdits fills the empty struct literal at the given position
+// with the zero values of all of its fields.
+func fillStructEdits(doc *store.Document, pkg *store.Package, pos protocol.Position) []protocol.TextEdit {
+	var lit *ast.CompositeLit
+	for _, n := range enclosingNodes(doc.Pgf.File, doc.PositionToPos(pos)) {
+		if cl, ok := n.(*ast.CompositeLit); ok {
+			lit = cl
+		}
+	}
+
+	if lit == nil || len(lit.Elts) > 0 {
+		return nil
+	}
+
+	// Only the package's own struct types have fields we know about.
+	id, ok := lit.Type.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+
+	st := findStruct(pkg, id.Name)
+	if st == nil {
+		return nil
+	}
+
+	fields := structFields(st)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	indent := lineIndent(doc, lit.Pos())
+	lines := strings.Split(fillStruct(fields, false), "\n")
+
+	return []protocol.TextEdit{{
+		Range: protocol.Range{
+			Start: doc.PosToPosition(lit.Lbrace),
+			End:   doc.PosToPosition(lit.Rbrace + 1),
+		},
+		NewText: "{\n" + indent + "\t" + strings.Join(lines, "\n"+indent+"\t") + "\n" + indent + "}",
+	}}
+}
+
+// typeSpecAt returns the (non-interface) type declaration whose name is at
+// the given position.
+func typeSpecAt(doc *store.Document, pos protocol.Position) *ast.TypeSpec {
+	for _, n := range enclosingNodes(doc.Pgf.File, doc.PositionToPos(pos)) {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+
+		if _, isIface := spec.Type.(*ast.InterfaceType); !isIface && spec.Name != nil {
+			p := doc.PositionToPos(pos)
+			if p >= spec.Name.Pos() && p <= spec.Name.End() {
+				return spec
+			}
+		}
+	}
+	return nil
+}
+
+// implementCandidates returns the interfaces that the type could implement:
+// those declared in its package or in a package the file imports (including
+// the indexed ones), which it doesn't implement yet. Interfaces whose method
+// signatures we don't know can't be stubbed, so they're skipped.
+func implementCandidates(doc *store.Document, pkg *store.Package, idx *typeIndex, spec *ast.TypeSpec) []namedType {
+	pkgs := map[string]bool{packageDetail(pkg): true}
+	for _, imp := range doc.Pgf.File.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+			pkgs[path] = true
+		}
+	}
+
+	t, found := idx.lookup(typeKey{Pkg: packageDetail(pkg), Name: spec.Name.Name})
+	if !found {
+		return nil
+	}
+
+	candidates := []namedType{}
+	for _, other := range idx.types {
+		if other.Iface && pkgs[other.Key.Pkg] && len(other.Sigs) > 0 && !implements(t, other) {
+			candidates = append(candidates, other)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Key.Pkg != candidates[j].Key.Pkg {
+			return candidates[i].Key.Pkg < candidates[j].Key.Pkg
+		}
+		return candidates[i].Key.Name < candidates[j].Key.Name
+	})
+
+	return candidates
+}
+
+// implementEdits adds stubs for the interface's methods that the type is
+// missing, after its last method (or its declaration).
+func implementEdits(doc *store.Document, pkg *store.Package, idx *typeIndex, spec *ast.TypeSpec, iface namedType) []protocol.TextEdit {
+	t, _ := idx.lookup(typeKey{Pkg: packageDetail(pkg), Name: spec.Name.Name})
+	recv, after := receiverOf(doc, spec)
+
+	qualifier := ""
+	if iface.Key.Pkg != packageDetail(pkg) {
+		qualifier = importedName(doc, iface.Key.Pkg)
+	}
+
+	names := make([]string, 0, len(iface.Sigs))
+	for name := range iface.Sigs {
+		if _, has := t.Methods[name]; !has {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		sig := qualifySig(iface.Sigs[name], qualifier)
+		if sig == "" {
+			return nil
+		}
+		fmt.Fprintf(&b, "\n\nfunc (%s) %s {\n\tpanic(\"not implemented\")\n}", recv, sig)
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+
+	at := doc.PosToPosition(after)
+	return []protocol.TextEdit{{
+		Range:   protocol.Range{Start: at, End: at},
+		NewText: b.String(),
+	}}
+}
+
+// receiverOf returns the receiver to use for new methods of the type (the
+// same as its existing methods, if any) and the position after which to add
+// them.
+func receiverOf(doc *store.Document, spec *ast.TypeSpec) (string, token.Pos) {
+	name := spec.Name.Name
+	recv := fmt.Sprintf("%c *%s", unicode.ToLower(rune(name[0])), name)
+
+	after := spec.End()
+	for _, decl := range doc.Pgf.File.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Pos() <= spec.Pos() && spec.End() <= gen.End() {
+			after = gen.End()
+		}
+	}
+
+	for _, decl := range doc.Pgf.File.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+			continue
+		}
+
+		field := fn.Recv.List[0]
+		if embeddedName(field.Type) != name {
+			continue
+		}
+
+		recv = exprString(field.Type)
+		if len(field.Names) > 0 && field.Names[0].Name != "_" {
+			recv = field.Names[0].Name + " " + recv
+		}
+		if fn.End() > after {
+			after = fn.End()
+		}
+	}
+
+	return recv, after
+}
+
+// qualifySig qualifies the exported types of a method signature from another
+// package.
+func qualifySig(sig, qualifier string) string {
+	fn := parseSignature("func " + sig)
+	if fn == nil {
+		return ""
+	}
+
+	if qualifier != "" {
+		qualifyExpr(fn.Type, qualifier)
+	}
+	return fn.Name.Name + strings.TrimPrefix(exprString(fn.Type), "func")
+}
+
+// importedName returns the name the file refers to the given import path
+// by.
+func importedName(doc *store.Document, path string) string {
+	for _, imp := range doc.Pgf.File.Imports {
+		if imp.Path.Value == strconv.Quote(path) {
+			return importName(imp)
+		}
+	}
+	return pkgName(path)
+}
+
+func ifaceName(doc *store.Document, pkg *store.Package, key typeKey) string {
+	if key.Pkg == packageDetail(pkg) {
+		return key.Name
+	}
+	return importedName(doc, key.Pkg) + "." + key.Name
+}
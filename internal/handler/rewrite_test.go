@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestFillStruct(t *testing.T) {
+	doc := loadTestDoc(t, "rewrite/p/shapes/shapes.gno")
+
+	pkg, err := store.NewDocumentStore().LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Inside `&Square{}`.
+	edits := fillStructEdits(doc, pkg, protocol.Position{Line: 15, Character: 17})
+	if len(edits) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, len(edits))
+	}
+
+	expected := "{\n\t\tSide: 0,\n\t\tLabel: \"\",\n\t}"
+	if edits[0].NewText != expected {
+		t.Errorf("expected = %q, got = %q", expected, edits[0].NewText)
+	}
+}
+
+func TestImplementInterface(t *testing.T) {
+	doc := loadTestDoc(t, "rewrite/p/shapes/shapes.gno")
+
+	ds := store.NewDocumentStore()
+	pkg, err := ds.LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := ds.LoadWorkspace(filepath.Dir(doc.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := buildTypeIndex(pkgs)
+
+	spec := typeSpecAt(doc, protocol.Position{Line: 7, Character: 6})
+	if spec == nil {
+		t.Fatal("expected a type spec")
+	}
+
+	candidates := implementCandidates(doc, pkg, idx, spec)
+	if len(candidates) != 1 || candidates[0].Key.Name != "Shape" {
+		t.Fatalf("expected = %v, got = %v", "Shape", candidates)
+	}
+
+	edits := implementEdits(doc, pkg, idx, spec, candidates[0])
+	expected := "\n\nfunc (s *Square) Scale(factor int) Shape {\n\tpanic(\"not implemented\")\n}"
+	if len(edits) != 1 || edits[0].NewText != expected {
+		t.Errorf("expected = %q, got = %v", expected, edits)
+	}
+
+	// After the existing `Area` method.
+	if edits[0].Range.Start.Line != 12 {
+		t.Errorf("expected = %v, got = %v", 12, edits[0].Range.Start.Line)
+	}
+}
+
+func TestQualifySig(t *testing.T) {
+	got := qualifySig("Transfer(to std.Address, amount Amount) error", "grc20")
+	if got != "Transfer(to std.Address, amount grc20.Amount) error" {
+		t.Errorf("expected = %v, got = %v", "Transfer(to std.Address, amount grc20.Amount) error", got)
+	}
+}
+
+func TestImplementIndexedInterface(t *testing.T) {
+	doc := loadTestDoc(t, "rewrite/r/nft/nft.gno")
+
+	ds := store.NewDocumentStore()
+	pkg, err := ds.LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := ds.LoadWorkspace(filepath.Dir(doc.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := buildTypeIndex(pkgs)
+
+	spec := typeSpecAt(doc, protocol.Position{Line: 8, Character: 6})
+	if spec == nil {
+		t.Fatal("expected a type spec")
+	}
+
+	var iface *namedType
+	for _, c := range implementCandidates(doc, pkg, idx, spec) {
+		if c.Key.Name == "IGRC721" {
+			iface = &c
+		}
+	}
+	if iface == nil {
+		t.Fatalf("expected = %v, got = %v", "IGRC721", implementCandidates(doc, pkg, idx, spec))
+	}
+
+	if name := ifaceName(doc, pkg, iface.Key); name != "grc721.IGRC721" {
+		t.Errorf("expected = %v, got = %v", "grc721.IGRC721", name)
+	}
+
+	edits := implementEdits(doc, pkg, idx, spec, *iface)
+	if len(edits) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, len(edits))
+	}
+
+	stub := "func (t *Token) GetApproved(tid grc721.TokenID) (std.Address, error) {"
+	if !strings.Contains(edits[0].NewText, stub) {
+		t.Errorf("expected %q in %q", stub, edits[0].NewText)
+	}
+	if strings.Contains(edits[0].NewText, "OwnerOf") {
+		t.Errorf("expected no stub for %v, got = %q", "OwnerOf", edits[0].NewText)
+	}
+}
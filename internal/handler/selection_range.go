@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/token"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// NOTE: `go.lsp.dev/protocol` has the types but not the method name.
+const methodTextDocumentSelectionRange = "textDocument/selectionRange"
+
+func (h *handler) handleSelectionRange(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.SelectionRangeParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	ranges := []protocol.SelectionRange{}
+	for _, pos := range params.Positions {
+		ranges = append(ranges, selectionRange(doc, pos))
+	}
+
+	return reply(ctx, ranges, nil)
+}
+
+// selectionRange returns the chain of ranges, from innermost to outermost,
+// of the AST nodes enclosing the given position.
+func selectionRange(doc *store.Document, pos protocol.Position) protocol.SelectionRange {
+	// The spec requires a range for every position, so we fall back to an
+	// empty range at the position itself.
+	empty := protocol.SelectionRange{Range: protocol.Range{Start: pos, End: pos}}
+	if doc.Pgf == nil || doc.Pgf.FileSet == nil {
+		return empty
+	}
+
+	path := enclosingNodes(doc.Pgf.File, doc.PositionToPos(pos))
+	if len(path) == 0 {
+		return empty
+	}
+
+	var current *protocol.SelectionRange
+	for _, n := range path {
+		rng := doc.NodeRange(n)
+		if current != nil && current.Range == rng {
+			continue // e.g., an ExprStmt and its expression
+		}
+		current = &protocol.SelectionRange{Range: rng, Parent: current}
+	}
+
+	return *current
+}
+
+// enclosingNodes returns the nodes that contain the given position, from
+// outermost to innermost.
+func enclosingNodes(file *ast.File, pos token.Pos) []ast.Node {
+	path := []ast.Node{}
+	if !pos.IsValid() {
+		return path
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || pos > n.End() {
+			return false
+		}
+		path = append(path, n)
+		return true
+	})
+
+	return path
+}
@@ -0,0 +1,523 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// semanticTokenTypes is our legend's list of token types; a token's type is
+// encoded as its index in this list.
+var semanticTokenTypes = []protocol.SemanticTokenTypes{
+	protocol.SemanticTokenNamespace,
+	protocol.SemanticTokenType,
+	protocol.SemanticTokenFunction,
+	protocol.SemanticTokenMethod,
+	protocol.SemanticTokenParameter,
+	protocol.SemanticTokenProperty,
+	protocol.SemanticTokenVariable,
+	protocol.SemanticTokenKeyword,
+	protocol.SemanticTokenComment,
+	protocol.SemanticTokenString,
+	protocol.SemanticTokenNumber,
+}
+
+// semanticTokenModifiers is our legend's list of modifiers; a token's
+// modifiers are encoded as a bit set of indexes into this list.
+var semanticTokenModifiers = []protocol.SemanticTokenModifiers{
+	protocol.SemanticTokenModifierDeclaration,
+	protocol.SemanticTokenModifierReadonly,
+	protocol.SemanticTokenModifierDefaultLibrary,
+}
+
+const (
+	tokNamespace uint32 = iota
+	tokType
+	tokFunction
+	tokMethod
+	tokParameter
+	tokProperty
+	tokVariable
+	tokKeyword
+	tokComment
+	tokString
+	tokNumber
+)
+
+const (
+	modDeclaration uint32 = 1 << iota
+	modReadonly
+	modDefaultLibrary
+)
+
+// semanticTokensOptions is the server capability for semantic tokens.
+//
+// NOTE: `protocol.SemanticTokensOptions` is missing most of its fields.
+type semanticTokensOptions struct {
+	Legend protocol.SemanticTokensLegend `json:"legend"`
+	Range  bool                          `json:"range"`
+	Full   semanticTokensFull            `json:"full"`
+}
+
+type semanticTokensFull struct {
+	Delta bool `json:"delta"`
+}
+
+// semanticToken is a single token, in absolute coordinates.
+type semanticToken struct {
+	Line   uint32
+	Start  uint32 // in UTF-16 code units
+	Length uint32 // in UTF-16 code units
+	Type   uint32
+	Mods   uint32
+}
+
+// semanticCache remembers the last result sent for each document, so that
+// `full/delta` requests can be answered with edits.
+type semanticCache struct {
+	mu      sync.Mutex
+	counter int
+	results map[protocol.DocumentURI]protocol.SemanticTokens
+}
+
+func (c *semanticCache) store(uri protocol.DocumentURI, data []uint32) protocol.SemanticTokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.results == nil {
+		c.results = map[protocol.DocumentURI]protocol.SemanticTokens{}
+	}
+	c.counter++
+
+	result := protocol.SemanticTokens{ResultID: strconv.Itoa(c.counter), Data: data}
+	c.results[uri] = result
+
+	return result
+}
+
+func (c *semanticCache) previous(uri protocol.DocumentURI, id string) ([]uint32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, ok := c.results[uri]
+	if !ok || prev.ResultID != id {
+		return nil, false
+	}
+	return prev.Data, true
+}
+
+func semanticTokensProvider() semanticTokensOptions {
+	return semanticTokensOptions{
+		Legend: protocol.SemanticTokensLegend{
+			TokenTypes:     semanticTokenTypes,
+			TokenModifiers: semanticTokenModifiers,
+		},
+		Range: true,
+		Full:  semanticTokensFull{Delta: true},
+	}
+}
+
+func (h *handler) handleSemanticTokensFull(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.SemanticTokensParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	data := encodeSemanticTokens(semanticTokens(doc, nil))
+	return reply(ctx, h.semantic.store(params.TextDocument.URI, data), nil)
+}
+
+func (h *handler) handleSemanticTokensRange(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.SemanticTokensRangeParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	data := encodeSemanticTokens(semanticTokens(doc, &params.Range))
+	return reply(ctx, protocol.SemanticTokens{Data: data}, nil)
+}
+
+func (h *handler) handleSemanticTokensDelta(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.SemanticTokensDeltaParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	data := encodeSemanticTokens(semanticTokens(doc, nil))
+
+	prev, found := h.semantic.previous(params.TextDocument.URI, params.PreviousResultID)
+	result := h.semantic.store(params.TextDocument.URI, data)
+	if !found {
+		// We can't compute a delta, so we send everything.
+		return reply(ctx, result, nil)
+	}
+
+	return reply(ctx, protocol.SemanticTokensDelta{
+		ResultID: result.ResultID,
+		Edits:    diffSemanticTokens(prev, data),
+	}, nil)
+}
+
+// semanticTokens computes the tokens of the document, optionally limited to
+// the given range.
+//
+// Lexical tokens (keywords, literals, comments) come from the scanner and
+// identifiers are classified using the document's type information, so this
+// degrades gracefully when the document doesn't type-check.
+func semanticTokens(doc *store.Document, rng *protocol.Range) []semanticToken {
+	toks := lexicalTokens(doc)
+	if doc.Pgf != nil && doc.Pgf.FileSet != nil {
+		toks = append(toks, identTokens(doc)...)
+	}
+
+	sort.Slice(toks, func(i, j int) bool {
+		if toks[i].Line != toks[j].Line {
+			return toks[i].Line < toks[j].Line
+		}
+		return toks[i].Start < toks[j].Start
+	})
+
+	out := []semanticToken{}
+	for _, tok := range toks {
+		if rng != nil && (tok.Line < rng.Start.Line || tok.Line > rng.End.Line) {
+			continue
+		}
+		out = append(out, tok)
+	}
+
+	return out
+}
+
+// lexicalTokens scans the document for keywords, literals and comments.
+func lexicalTokens(doc *store.Document) []semanticToken {
+	toks := []semanticToken{}
+
+	src := []byte(doc.Content)
+	fset := token.NewFileSet()
+	file := fset.AddFile(doc.Path, -1, len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		var typ uint32
+		switch {
+		case tok.IsKeyword():
+			typ = tokKeyword
+		case tok == token.COMMENT:
+			typ = tokComment
+		case tok == token.STRING || tok == token.CHAR:
+			typ = tokString
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			typ = tokNumber
+		default:
+			continue
+		}
+
+		if lit == "" {
+			lit = tok.String()
+		}
+
+		p := fset.Position(pos)
+		toks = append(toks, splitToken(doc, p.Line, p.Column, lit, typ, 0)...)
+	}
+
+	return toks
+}
+
+// splitToken creates one token per line spanned by the given text, since
+// tokens can't span multiple lines.
+func splitToken(doc *store.Document, line, col int, text string, typ, mods uint32) []semanticToken {
+	toks := []semanticToken{}
+	for i, part := range strings.Split(text, "\n") {
+		if i > 0 {
+			col = 1
+		}
+
+		start := doc.LineColToPosition(line+i, col)
+		length := len(utf16.Encode([]rune(strings.TrimSuffix(part, "\r"))))
+		if length > 0 {
+			toks = append(toks, semanticToken{
+				Line:   start.Line,
+				Start:  start.Character,
+				Length: uint32(length),
+				Type:   typ,
+				Mods:   mods,
+			})
+		}
+	}
+	return toks
+}
+
+// identTokens classifies every identifier in the document.
+func identTokens(doc *store.Document) []semanticToken {
+	toks := []semanticToken{}
+	ti := doc.TypeInfo()
+
+	called := map[ast.Expr]bool{}
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			called[call.Fun] = true
+		}
+		return true
+	})
+
+	params := map[types.Object]bool{}
+	if ti != nil {
+		ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+			fn, ok := n.(*ast.FuncType)
+			if !ok {
+				return true
+			}
+			for _, list := range []*ast.FieldList{fn.Params, fn.Results} {
+				if list == nil {
+					continue
+				}
+				for _, f := range list.List {
+					for _, name := range f.Names {
+						params[ti.Info.Defs[name]] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	ast.Inspect(doc.Pgf.File, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.File:
+			// The package clause's name.
+			toks = append(toks, identToken(doc, node.Name, tokNamespace, modDeclaration))
+		case *ast.ImportSpec:
+			if node.Name != nil {
+				toks = append(toks, identToken(doc, node.Name, tokNamespace, modDeclaration))
+			}
+			return false
+		case *ast.SelectorExpr:
+			if tok, ok := classifyUnresolvedSelector(doc, ti, node, called[node]); ok {
+				toks = append(toks, tok)
+			}
+		case *ast.Ident:
+			if ti == nil || node.Name == "_" {
+				return true
+			}
+
+			typ, mods, ok := classifyObject(ti.ObjectOf(node), params)
+			if !ok {
+				return true
+			}
+
+			if ti.Info.Defs[node] != nil {
+				mods |= modDeclaration
+			}
+			toks = append(toks, identToken(doc, node, typ, mods))
+		}
+		return true
+	})
+
+	return toks
+}
+
+// classifyObject maps a type-checked object to a token type and modifiers.
+func classifyObject(obj types.Object, params map[types.Object]bool) (uint32, uint32, bool) {
+	if obj == nil {
+		return 0, 0, false
+	}
+	universe := obj.Parent() == types.Universe
+
+	var mods uint32
+	if universe {
+		mods |= modDefaultLibrary
+	}
+
+	switch o := obj.(type) {
+	case *types.PkgName:
+		return tokNamespace, mods, true
+	case *types.TypeName:
+		return tokType, mods, true
+	case *types.Builtin:
+		return tokFunction, mods, true
+	case *types.Func:
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return tokMethod, mods, true
+		}
+		return tokFunction, mods, true
+	case *types.Const:
+		return tokVariable, mods | modReadonly, true
+	case *types.Nil:
+		return tokVariable, mods | modReadonly, true
+	case *types.Var:
+		if o.IsField() {
+			return tokProperty, mods, true
+		} else if params[o] {
+			return tokParameter, mods, true
+		}
+		return tokVariable, mods, true
+	}
+
+	return 0, 0, false
+}
+
+// classifyUnresolvedSelector classifies the members of packages the type
+// checker doesn't know about (i.e., most of the Gno standard library, such as
+// `std.GetOrigCaller`) using our symbol index.
+//
+// Native functions (like those in `std`) aren't in the index, so we fall back
+// to treating called members as functions.
+func classifyUnresolvedSelector(doc *store.Document, ti *store.TypeInfo, sel *ast.SelectorExpr, called bool) (semanticToken, bool) {
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return semanticToken{}, false
+	} else if ti != nil && ti.ObjectOf(sel.Sel) != nil {
+		return semanticToken{}, false
+	} else if ti != nil {
+		// Only consider package names (or names we couldn't resolve).
+		obj := ti.ObjectOf(x)
+		if _, isPkg := obj.(*types.PkgName); obj != nil && !isPkg {
+			return semanticToken{}, false
+		}
+	}
+
+	specs := importsNamed(doc.Pgf.File, x.Name)
+	if len(specs) == 0 {
+		return semanticToken{}, false
+	}
+
+	var mods uint32
+	if !strings.Contains(specs[0].Path.Value, ".") {
+		mods |= modDefaultLibrary
+	}
+
+	sym := lookupSymbolByImports(sel.Sel.Name, specs)
+	if sym == nil && called {
+		return identToken(doc, sel.Sel, tokFunction, mods), true
+	} else if sym == nil {
+		return semanticToken{}, false
+	}
+
+	typ := tokVariable
+	switch symbolToKind(sym.Kind) { //nolint:exhaustive
+	case protocol.CompletionItemKindFunction:
+		typ = tokFunction
+	case protocol.CompletionItemKindClass, protocol.CompletionItemKindStruct, protocol.CompletionItemKindInterface:
+		typ = tokType
+	case protocol.CompletionItemKindConstant:
+		mods |= modReadonly
+	}
+
+	return identToken(doc, sel.Sel, typ, mods), true
+}
+
+// importsNamed returns the file's imports that are referred to by the given
+// name.
+func importsNamed(file *ast.File, name string) []*ast.ImportSpec {
+	specs := []*ast.ImportSpec{}
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		if spec.Name != nil && spec.Name.Name == name {
+			specs = append(specs, spec)
+		} else if spec.Name == nil && path[strings.LastIndex(path, "/")+1:] == name {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+func identToken(doc *store.Document, id *ast.Ident, typ, mods uint32) semanticToken {
+	start := doc.PosToPosition(id.Pos())
+	return semanticToken{
+		Line:   start.Line,
+		Start:  start.Character,
+		Length: uint32(len(utf16.Encode([]rune(id.Name)))),
+		Type:   typ,
+		Mods:   mods,
+	}
+}
+
+// encodeSemanticTokens converts the tokens into the LSP's relative encoding.
+func encodeSemanticTokens(toks []semanticToken) []uint32 {
+	data := make([]uint32, 0, len(toks)*5)
+
+	var line, start uint32
+	for _, tok := range toks {
+		deltaStart := tok.Start
+		if tok.Line == line {
+			deltaStart = tok.Start - start
+		}
+		data = append(data, tok.Line-line, deltaStart, tok.Length, tok.Type, tok.Mods)
+		line, start = tok.Line, tok.Start
+	}
+
+	return data
+}
+
+// diffSemanticTokens computes a single edit that turns prev into next, by
+// trimming their common prefix and suffix.
+func diffSemanticTokens(prev, next []uint32) []protocol.SemanticTokensEdit {
+	prefix := 0
+	for prefix < len(prev) && prefix < len(next) && prev[prefix] == next[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(prev)-prefix && suffix < len(next)-prefix &&
+		prev[len(prev)-1-suffix] == next[len(next)-1-suffix] {
+		suffix++
+	}
+
+	if prefix == len(prev) && prefix == len(next) {
+		return []protocol.SemanticTokensEdit{}
+	}
+
+	return []protocol.SemanticTokensEdit{{
+		Start:       uint32(prefix),
+		DeleteCount: uint32(len(prev) - prefix - suffix),
+		Data:        next[prefix : len(next)-suffix],
+	}}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// loadTestDoc reads a file from `testdata` into a document.
+func loadTestDoc(t *testing.T, path string) *store.Document {
+	t.Helper()
+
+	filePath, err := filepath.Abs(filepath.Join("../../testdata", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dat, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pgf, err := store.NewParsedGnoFile(filePath, string(dat))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &store.Document{
+		Path:    filePath,
+		Content: string(dat),
+		Lines:   strings.SplitAfter(string(dat), "\n"),
+		Pgf:     pgf,
+	}
+}
+
+func TestSemanticTokens(t *testing.T) {
+	doc := loadTestDoc(t, "semantic/realm.gno")
+	toks := semanticTokens(doc, nil)
+
+	find := func(line, start uint32) *semanticToken {
+		for i := range toks {
+			if toks[i].Line == line && toks[i].Start == start {
+				return &toks[i]
+			}
+		}
+		return nil
+	}
+
+	cases := []struct {
+		name       string
+		line, char uint32
+		typ, mods  uint32
+	}{
+		{"package", 0, 8, tokNamespace, modDeclaration},
+		{"const", 7, 6, tokVariable, modDeclaration | modReadonly},
+		{"struct", 9, 5, tokType, modDeclaration},
+		{"field", 10, 1, tokProperty, modDeclaration},
+		{"std", 10, 7, tokNamespace, 0},
+		{"param", 16, 15, tokParameter, modDeclaration},
+		{"GetOrigCaller", 17, 15, tokFunction, modDefaultLibrary},
+		{"panic", 19, 2, tokFunction, modDefaultLibrary},
+		{"keyword", 22, 1, tokKeyword, 0},
+	}
+
+	for _, tc := range cases {
+		tok := find(tc.line, tc.char)
+		if tok == nil {
+			t.Errorf("%s: no token at %d:%d", tc.name, tc.line, tc.char)
+			continue
+		}
+
+		if tok.Type != tc.typ || tok.Mods != tc.mods {
+			t.Errorf("%s: expected = %d/%d, got = %d/%d", tc.name, tc.typ, tc.mods, tok.Type, tok.Mods)
+		}
+	}
+}
+
+func TestDiffSemanticTokens(t *testing.T) {
+	prev := []uint32{0, 0, 7, 7, 0, 0, 8, 7, 0, 1}
+	next := []uint32{0, 0, 7, 7, 0, 2, 0, 3, 7, 0, 0, 8, 7, 0, 1}
+
+	edits := diffSemanticTokens(prev, next)
+	if len(edits) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, len(edits))
+	}
+
+	if edits[0].Start != 5 || edits[0].DeleteCount != 0 || len(edits[0].Data) != 5 {
+		t.Errorf("unexpected edit: %+v", edits[0])
+	}
+}
+
+func TestSemanticTokensPartialAST(t *testing.T) {
+	doc := loadTestDoc(t, "semantic/realm.gno")
+	doc.ApplyChanges([]protocol.TextDocumentContentChangeEvent{
+		{Text: "package realm\n\nfunc Render(path string) string {\n\treturn path +\n"},
+	})
+
+	if doc.Pgf == nil || doc.Pgf.Err == nil {
+		t.Fatalf("expected a partial AST, got = %+v", doc.Pgf)
+	}
+
+	tf := doc.Pgf.FileSet.File(doc.Pgf.File.Pos())
+	if tf.Size() != len(doc.Content) {
+		t.Errorf("expected = %v, got = %v", len(doc.Content), tf.Size())
+	}
+
+	for _, tok := range semanticTokens(doc, nil) {
+		if int(tok.Line) >= len(doc.Lines) || int(tok.Start+tok.Length) > len(doc.Lines[tok.Line]) {
+			t.Errorf("token out of range: %+v", tok)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.lsp.dev/protocol"
+)
+
+// snippetsFile is the workspace-relative path to user-defined snippets.
+var snippetsFile = filepath.Join(".gnols", "snippets.json")
+
+// A snippet is a template inserted by the client, using the LSP snippet
+// syntax (`$1`, `${2:name}`, `$0`).
+type snippet struct {
+	Prefix      string      `json:"prefix"`
+	Body        snippetBody `json:"body"`
+	Description string      `json:"description"`
+	// Suffix limits the snippet to files ending with the given string (e.g.,
+	// `_test.gno`).
+	Suffix string `json:"suffix"`
+}
+
+// snippetBody is either a single string or a list of lines, as in VS Code's
+// snippet files.
+type snippetBody []string
+
+func (b *snippetBody) UnmarshalJSON(data []byte) error {
+	var line string
+	if err := json.Unmarshal(data, &line); err == nil {
+		*b = strings.Split(line, "\n")
+		return nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*b = lines
+
+	return nil
+}
+
+// builtinSnippets are the Gno idioms we offer out of the box.
+var builtinSnippets = []snippet{
+	{
+		Prefix: "render",
+		Body: snippetBody{
+			"func Render(${1:path} string) string {",
+			"\t${0:return \"\"}",
+			"}",
+		},
+		Description: "Realm Render function",
+	},
+	{
+		Prefix: "caller",
+		Body: snippetBody{
+			"${1:caller} := std.GetOrigCaller()",
+			"if ${1:caller} != ${2:admin} {",
+			"\tpanic(\"${3:unauthorized}\")",
+			"}",
+		},
+		Description: "Check the origin caller",
+	},
+	{
+		Prefix: "origincall",
+		Body: snippetBody{
+			"std.AssertOriginCall()",
+		},
+		Description: "Assert that the call comes from a user",
+	},
+	{
+		Prefix: "avltree",
+		Body: snippetBody{
+			"var ${1:tree} = avl.NewTree()",
+		},
+		Description: "Declare an AVL tree",
+	},
+	{
+		Prefix: "avliter",
+		Body: snippetBody{
+			"${1:tree}.Iterate(\"${2}\", \"${3}\", func(key string, value interface{}) bool {",
+			"\t$0",
+			"\treturn false",
+			"})",
+		},
+		Description: "Iterate over an AVL tree",
+	},
+	{
+		Prefix: "test",
+		Body: snippetBody{
+			"func Test${1:Name}(t *testing.T) {",
+			"\t$0",
+			"}",
+		},
+		Description: "Test function",
+		Suffix:      "_test.gno",
+	},
+	{
+		Prefix: "output",
+		Body: snippetBody{
+			"// Output:",
+			"// $0",
+		},
+		Description: "Filetest expected output",
+		Suffix:      "_filetest.gno",
+	},
+}
+
+// loadSnippets reads the user-defined snippets from the workspace, if any.
+//
+// The file uses the same layout as VS Code's snippet files:
+//
+//	{
+//	  "Admin check": {
+//	    "prefix": "admin",
+//	    "body": ["if std.GetOrigCaller() != admin {", "\tpanic($1)", "}"],
+//	    "description": "Panic unless called by admin"
+//	  }
+//	}
+func loadSnippets(root string) ([]snippet, error) {
+	if root == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, snippetsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	named := map[string]snippet{}
+	if err = json.Unmarshal(data, &named); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	snippets := make([]snippet, 0, len(named))
+	for _, name := range names {
+		s := named[name]
+		if s.Prefix == "" {
+			s.Prefix = name
+		}
+		if s.Description == "" {
+			s.Description = name
+		}
+		snippets = append(snippets, s)
+	}
+
+	return snippets, nil
+}
+
+// snippetCompletions returns the snippets that apply to the given file and
+// match the text typed so far.
+func (h *handler) snippetCompletions(path, text string) []protocol.CompletionItem {
+	items := []protocol.CompletionItem{}
+	if !h.snippetSupport {
+		return items
+	}
+
+	all := append(append([]snippet{}, builtinSnippets...), h.snippets...)
+	for _, s := range all {
+		if s.Suffix != "" && !strings.HasSuffix(path, s.Suffix) {
+			continue
+		} else if !strings.HasPrefix(s.Prefix, text) {
+			continue
+		}
+
+		items = append(items, protocol.CompletionItem{
+			Label:            s.Prefix,
+			Kind:             protocol.CompletionItemKindSnippet,
+			Detail:           s.Description,
+			InsertText:       strings.Join(s.Body, "\n"),
+			InsertTextFormat: protocol.InsertTextFormatSnippet,
+		})
+	}
+
+	slog.Info("completion", "snippets", len(items))
+	return items
+}
@@ -0,0 +1,36 @@
+package handler
+
+import "testing"
+
+func TestLoadSnippets(t *testing.T) {
+	snippets, err := loadSnippets("../../testdata/snippets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snippets) != 2 {
+		t.Fatalf("expected = %v, got = %v", 2, len(snippets))
+	}
+
+	if snippets[0].Prefix != "admin" || len(snippets[0].Body) != 3 {
+		t.Errorf("unexpected snippet: %v", snippets[0])
+	}
+
+	if snippets[1].Prefix != "Coins" || len(snippets[1].Body) != 1 {
+		t.Errorf("unexpected snippet: %v", snippets[1])
+	}
+}
+
+func TestSnippetCompletions(t *testing.T) {
+	h := &handler{snippetSupport: true}
+
+	items := h.snippetCompletions("/tmp/foo_test.gno", "te")
+	if len(items) != 1 || items[0].Label != "test" {
+		t.Errorf("expected the test snippet, got = %v", items)
+	}
+
+	items = h.snippetCompletions("/tmp/foo.gno", "te")
+	if len(items) != 0 {
+		t.Errorf("expected no snippets, got = %v", items)
+	}
+}
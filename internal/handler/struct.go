@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// A structField is a single (named) field of a struct type.
+type structField struct {
+	Name string
+	Type ast.Expr
+}
+
+// compositeLit describes the composite literal surrounding the cursor.
+type compositeLit struct {
+	Type    string          // the literal's type name (e.g., `Post`)
+	Present map[string]bool // fields that are already set
+}
+
+// compositeLitAt returns the innermost composite literal whose braces
+// contain the given position, if any.
+//
+// The literal is usually incomplete while the user is typing it, so we rely
+// on the parser's partial AST (where the closing brace may be at EOF).
+func compositeLitAt(file *ast.File, pos token.Pos) *compositeLit {
+	var lit *ast.CompositeLit
+	for _, n := range enclosingNodes(file, pos) {
+		if cl, ok := n.(*ast.CompositeLit); ok && pos > cl.Lbrace && pos <= cl.Rbrace {
+			lit = cl
+		}
+	}
+
+	if lit == nil {
+		return nil
+	}
+
+	name := ""
+	switch t := lit.Type.(type) {
+	case *ast.Ident:
+		name = t.Name
+	case *ast.SelectorExpr:
+		name = exprString(t)
+	default:
+		return nil
+	}
+
+	present := map[string]bool{}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		} else if pos > kv.Colon && pos <= kv.End() {
+			return nil // we're in a field's value, not its name
+		}
+
+		if key, isIdent := kv.Key.(*ast.Ident); isIdent {
+			present[key.Name] = true
+		}
+	}
+
+	return &compositeLit{Type: name, Present: present}
+}
+
+// findStruct looks up the named struct type in the given package.
+func findStruct(pkg *store.Package, name string) *ast.StructType {
+	for _, path := range pkg.Paths() {
+		for _, decl := range pkg.Files[path].Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				ts, isType := spec.(*ast.TypeSpec)
+				if !isType || ts.Name.Name != name {
+					continue
+				}
+
+				st, isStruct := ts.Type.(*ast.StructType)
+				if isStruct {
+					return st
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// structFields flattens the fields of a struct type (so `A, B int` becomes
+// two fields). Embedded fields are named after their type.
+func structFields(st *ast.StructType) []structField {
+	fields := []structField{}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			fields = append(fields, structField{
+				Name: embeddedName(f.Type),
+				Type: f.Type,
+			})
+			continue
+		}
+
+		for _, n := range f.Names {
+			fields = append(fields, structField{Name: n.Name, Type: f.Type})
+		}
+	}
+	return fields
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	default:
+		return exprString(expr)
+	}
+}
+
+// zeroValue returns the source text of the zero value for the given type.
+func zeroValue(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return `""`
+		case "bool":
+			return "false"
+		case "error", "any":
+			return "nil"
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+			"float32", "float64", "complex64", "complex128",
+			"byte", "rune":
+			return "0"
+		}
+		return t.Name + "{}"
+	case *ast.SelectorExpr:
+		// We don't know the underlying type of imported names, except for a
+		// few common ones.
+		switch exprString(t) {
+		case "std.Address":
+			return `""`
+		}
+		return exprString(t) + "{}"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "nil"
+		}
+		return exprString(t) + "{}"
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return "nil"
+	case *ast.StructType:
+		return exprString(t) + "{}"
+	default:
+		return "nil"
+	}
+}
+
+// exprString returns the source text of the given expression.
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, token.NewFileSet(), expr); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// fillStruct returns the text of the `Field: value,` lines for the given
+// fields, using snippet placeholders if requested.
+func fillStruct(fields []structField, snippets bool) string {
+	lines := make([]string, 0, len(fields))
+	for i, f := range fields {
+		value := zeroValue(f.Type)
+		if snippets {
+			value = fmt.Sprintf("${%d:%s}", i+1, escapeSnippet(value))
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s,", f.Name, value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeSnippet escapes the characters that are special in snippet text.
+func escapeSnippet(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`).Replace(s)
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// subtest is a `t.Run` subtest whose name is known statically.
+type subtest struct {
+	Name string   // the full name, as `gno test -run` sees it: TestX/case
+	Node ast.Node // the `t.Run` call, or the table case
+}
+
+// subtestCase is a possible name of a `t.Run` subtest.
+type subtestCase struct {
+	name string
+	node ast.Node
+}
+
+// subtests returns the subtests of a test function: those run with a
+// literal name, and those of table-driven tests whose names are string
+// literals in the table.
+func subtests(fn *ast.FuncDecl) []subtest {
+	if fn.Body == nil {
+		return nil
+	}
+	return findSubtests(fn.Body, testParam(fn.Type), fn.Name.Name)
+}
+
+// testParam returns the name of the `*testing.T` parameter, if it has one.
+func testParam(ft *ast.FuncType) string {
+	if ft.Params == nil || len(ft.Params.List) != 1 || len(ft.Params.List[0].Names) != 1 {
+		return ""
+	}
+
+	name := ft.Params.List[0].Names[0].Name
+	if name == "_" {
+		return ""
+	}
+	return name
+}
+
+func findSubtests(body *ast.BlockStmt, t, prefix string) []subtest {
+	found := []subtest{}
+	if t == "" {
+		return found
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || !isRunCall(call, t) {
+			return true
+		}
+
+		for _, c := range subtestCases(body, call) {
+			name := prefix + "/" + rewriteSubtestName(c.name)
+			found = append(found, subtest{Name: name, Node: c.node})
+
+			if lit, isLit := call.Args[1].(*ast.FuncLit); isLit {
+				found = append(found, findSubtests(lit.Body, testParam(lit.Type), name)...)
+			}
+		}
+
+		// Nested subtests were handled above, with their parent's name.
+		return false
+	})
+
+	return found
+}
+
+// isRunCall reports whether the call is `t.Run(name, f)`.
+func isRunCall(call *ast.CallExpr, t string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Run" || len(call.Args) != 2 {
+		return false
+	}
+
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == t
+}
+
+// subtestCases returns the names a `t.Run` call can run with: its literal
+// name or, for `t.Run(tt.name, ...)` and `t.Run(name, ...)` in a loop over
+// a table, the names in the table.
+func subtestCases(body *ast.BlockStmt, call *ast.CallExpr) []subtestCase {
+	switch arg := call.Args[0].(type) {
+	case *ast.BasicLit:
+		if name, ok := stringLit(arg); ok {
+			return []subtestCase{{name: name, node: call}}
+		}
+	case *ast.SelectorExpr:
+		if id, ok := arg.X.(*ast.Ident); ok {
+			if table, elt := rangeTable(body, id.Name, false); table != nil {
+				return fieldCases(table, elt, arg.Sel.Name)
+			}
+		}
+	case *ast.Ident:
+		if table, _ := rangeTable(body, arg.Name, true); table != nil {
+			return keyCases(table)
+		}
+	}
+	return nil
+}
+
+// rangeTable finds the table that a `for key, value := range table` loop
+// iterates over, where name is the value (or the key), along with the
+// table's element type.
+func rangeTable(body *ast.BlockStmt, name string, key bool) (*ast.CompositeLit, ast.Expr) {
+	var table *ast.CompositeLit
+	var elt ast.Expr
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		rs, ok := n.(*ast.RangeStmt)
+		if !ok || table != nil {
+			return table == nil
+		}
+
+		v := rs.Value
+		if key {
+			v = rs.Key
+		}
+		if id, isIdent := v.(*ast.Ident); !isIdent || id.Name != name {
+			return true
+		}
+
+		lit := tableLit(body, rs.X)
+		if lit == nil {
+			return true
+		}
+
+		switch t := lit.Type.(type) {
+		case *ast.ArrayType:
+			if !key {
+				table, elt = lit, t.Elt
+			}
+		case *ast.MapType:
+			table, elt = lit, t.Value
+		}
+		return table == nil
+	})
+
+	return table, elt
+}
+
+// tableLit resolves the table of a range loop: a literal, or a variable
+// assigned one in the function.
+func tableLit(body *ast.BlockStmt, x ast.Expr) *ast.CompositeLit {
+	if lit, ok := x.(*ast.CompositeLit); ok {
+		return lit
+	}
+
+	id, ok := x.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+
+	var lit *ast.CompositeLit
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range stmt.Lhs {
+				if l, isIdent := lhs.(*ast.Ident); isIdent && l.Name == id.Name && i < len(stmt.Rhs) {
+					if cl, isLit := stmt.Rhs[i].(*ast.CompositeLit); isLit {
+						lit = cl
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, n := range stmt.Names {
+				if n.Name == id.Name && i < len(stmt.Values) {
+					if cl, isLit := stmt.Values[i].(*ast.CompositeLit); isLit {
+						lit = cl
+					}
+				}
+			}
+		}
+		return lit == nil
+	})
+
+	return lit
+}
+
+// fieldCases returns the values of a string field of a table's cases.
+func fieldCases(table *ast.CompositeLit, elt ast.Expr, field string) []subtestCase {
+	index := -1
+	if st, ok := elt.(*ast.StructType); ok {
+		i := 0
+		for _, f := range st.Fields.List {
+			for _, n := range f.Names {
+				if n.Name == field {
+					index = i
+				}
+				i++
+			}
+		}
+	}
+
+	cases := []subtestCase{}
+	for _, e := range table.Elts {
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			e = kv.Value // map tables
+		}
+
+		lit, ok := e.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+
+		var value ast.Expr
+		for i, elem := range lit.Elts {
+			if kv, isKV := elem.(*ast.KeyValueExpr); isKV {
+				if k, isIdent := kv.Key.(*ast.Ident); isIdent && k.Name == field {
+					value = kv.Value
+				}
+			} else if i == index {
+				value = elem
+			}
+		}
+
+		if name, found := stringLit(value); found {
+			cases = append(cases, subtestCase{name: name, node: lit})
+		}
+	}
+
+	return cases
+}
+
+// keyCases returns the keys of a map table.
+func keyCases(table *ast.CompositeLit) []subtestCase {
+	cases := []subtestCase{}
+	for _, e := range table.Elts {
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			if name, found := stringLit(kv.Key); found {
+				cases = append(cases, subtestCase{name: name, node: kv})
+			}
+		}
+	}
+	return cases
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil && s != ""
+}
+
+// rewriteSubtestName rewrites a subtest's name like the testing package
+// does: spaces become underscores.
+func rewriteSubtestName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
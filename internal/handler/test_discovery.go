@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+const (
+	// methodDiscoverTests returns every test in the workspace.
+	methodDiscoverTests = "gnols/discoverTests"
+	// methodTestsChanged is sent when a package's tests change, once the
+	// client has discovered them.
+	methodTestsChanged = "gnols/testsChanged"
+)
+
+// The kinds of test items.
+const (
+	testItemPackage   = "package"
+	testItemFile      = "file"
+	testItemTest      = "test"
+	testItemSubtest   = "subtest"
+	testItemBenchmark = "benchmark"
+	testItemFiletest  = "filetest"
+)
+
+// testItem is a node of the test tree: packages contain files, which
+// contain tests (and their subtests) and benchmarks. Filetests are files
+// without children.
+type testItem struct {
+	ID       string               `json:"id"`
+	Label    string               `json:"label"`
+	Kind     string               `json:"kind"`
+	URI      protocol.DocumentURI `json:"uri,omitempty"`
+	Range    *protocol.Range      `json:"range,omitempty"`
+	Command  *protocol.Command    `json:"command,omitempty"` // runs the item
+	Children []testItem           `json:"children,omitempty"`
+}
+
+type discoverTestsParams struct {
+	// URI is the directory to search; the workspace root by default.
+	URI protocol.DocumentURI `json:"uri,omitempty"`
+}
+
+type discoverTestsResult struct {
+	Packages []testItem `json:"packages"`
+}
+
+// testsChangedParams replaces a package in the test tree; a package with no
+// children has been removed.
+type testsChangedParams struct {
+	Package testItem `json:"package"`
+}
+
+// testTree tracks the packages sent to the client, so that only actual
+// changes are notified.
+type testTree struct {
+	mu       sync.Mutex
+	packages map[string]testItem // keyed by directory; nil until discovered
+}
+
+// update records the package, reporting whether it changed.
+func (t *testTree) update(item testItem) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.packages == nil {
+		return false
+	} else if old, ok := t.packages[item.ID]; ok && reflect.DeepEqual(old, item) {
+		return false
+	} else if !ok && len(item.Children) == 0 {
+		return false
+	}
+
+	t.packages[item.ID] = item
+	return true
+}
+
+// discovered reports whether the client asked for the tests, so that we
+// have to keep it up to date.
+func (t *testTree) discovered() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.packages != nil
+}
+
+func (t *testTree) reset(items []testItem) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.packages = map[string]testItem{}
+	for _, item := range items {
+		t.packages[item.ID] = item
+	}
+}
+
+func (h *handler) handleDiscoverTests(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params discoverTestsParams
+
+	if req.Params() != nil {
+		if err := json.Unmarshal(req.Params(), &params); err != nil {
+			return badJSON(ctx, reply, err)
+		}
+	}
+
+	root := h.rootDir
+	if params.URI != "" {
+		root = params.URI.Filename()
+	}
+
+	if root == "" {
+		return reply(ctx, discoverTestsResult{Packages: []testItem{}}, nil)
+	}
+
+	pkgs, err := h.documents.LoadWorkspace(root)
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	items := []testItem{}
+	for _, pkg := range pkgs {
+		if item := packageTests(pkg); len(item.Children) > 0 {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	slog.Info("discover_tests", "root", root, "packages", len(items))
+	h.tests.reset(items)
+
+	return reply(ctx, discoverTestsResult{Packages: items}, nil)
+}
+
+// refreshTests notifies the client if the tests of the file's package
+// changed (including the package going away), once it discovered them.
+//
+// It loads the whole package, so it's called when a file is opened, saved or
+// closed rather than on every change.
+func (h *handler) refreshTests(ctx context.Context, path string) {
+	if !store.IsTestFile(path) || !h.tests.discovered() {
+		return
+	}
+	dir := filepath.Dir(path)
+
+	item := testItem{ID: dir, Label: filepath.Base(dir), Kind: testItemPackage, URI: uri.File(dir), Children: []testItem{}}
+	if pkg, err := h.documents.LoadPackage(dir); err == nil {
+		item = packageTests(pkg)
+	}
+
+	if h.tests.update(item) {
+		h.notify(ctx, methodTestsChanged, testsChangedParams{Package: item})
+	}
+}
+
+// handleDidChangeWatchedFiles drops the type index and refreshes the tests
+// of the packages whose test files were created, changed or deleted on disk.
+func (h *handler) handleDidChangeWatchedFiles(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.DidChangeWatchedFilesParams
+
+	if req.Params() == nil {
+		return reply(ctx, nil, nil)
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	h.types.invalidate()
+
+	refreshed := map[string]bool{}
+	for _, change := range params.Changes {
+		path, err := store.CanonicalPath(change.URI)
+		if err != nil {
+			continue
+		}
+
+		if dir := filepath.Dir(path); store.IsTestFile(path) && !refreshed[dir] {
+			refreshed[dir] = true
+			h.refreshTests(ctx, path)
+		}
+	}
+
+	return reply(ctx, nil, nil)
+}
+
+// watchGnoFiles asks the client to tell us about the Gno files that change
+// on disk (e.g., that are created or deleted outside the editor), so that we
+// can refresh their tests and the type index.
+func (h *handler) watchGnoFiles(ctx context.Context) {
+	params := protocol.RegistrationParams{
+		Registrations: []protocol.Registration{{
+			ID:     "gnols-gno-files",
+			Method: protocol.MethodWorkspaceDidChangeWatchedFiles,
+			RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{
+				Watchers: []protocol.FileSystemWatcher{
+					{GlobPattern: "**/*.gno"},
+				},
+			},
+		}},
+	}
+
+	if _, err := h.connPool.Call(ctx, protocol.MethodClientRegisterCapability, params, nil); err != nil {
+		slog.Warn("watch_gno_files", "error", err)
+	}
+}
+
+// packageTests returns the test tree of a package.
+func packageTests(pkg *store.Package) testItem {
+	label := pkg.ImportPath
+	if label == "" {
+		label = filepath.Base(pkg.Dir)
+	}
+
+	item := testItem{
+		ID:       pkg.Dir,
+		Label:    label,
+		Kind:     testItemPackage,
+		URI:      uri.File(pkg.Dir),
+		Children: []testItem{},
+	}
+
+	paths := make([]string, 0, len(pkg.Files))
+	for path := range pkg.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if isFiletest(path) {
+			item.Children = append(item.Children, filetestItem(path))
+		} else if store.IsTestFile(path) {
+			if file := fileTests(pkg, path); len(file.Children) > 0 {
+				item.Children = append(item.Children, file)
+			}
+		}
+	}
+
+	// Any of the package's files will do, since there are no names.
+	if len(item.Children) > 0 {
+		if args, err := newCommandArgs(item.Children[0].ID); err == nil {
+			item.Command = &protocol.Command{Title: "run package tests", Command: "gnols.test", Arguments: []interface{}{args}}
+		}
+	}
+
+	return item
+}
+
+func filetestItem(path string) testItem {
+	item := testItem{
+		ID:    path,
+		Label: filepath.Base(path),
+		Kind:  testItemFiletest,
+		URI:   uri.File(path),
+	}
+	if args, err := newCommandArgs(path); err == nil {
+		item.Command = &protocol.Command{Title: "run filetest", Command: "gnols.filetest", Arguments: []interface{}{args}}
+	}
+	return item
+}
+
+// fileTests returns the tests and benchmarks declared in a test file.
+func fileTests(pkg *store.Package, path string) testItem {
+	item := testItem{
+		ID:       path,
+		Label:    filepath.Base(path),
+		Kind:     testItemFile,
+		URI:      uri.File(path),
+		Children: []testItem{},
+	}
+
+	names := []string{}
+	for _, decl := range pkg.Files[path].Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil {
+			continue
+		}
+
+		kind, cmd := "", ""
+		if matchTestFunc(fn, testRe, "T") {
+			kind, cmd = testItemTest, "gnols.test"
+			names = append(names, fn.Name.Name)
+		} else if matchTestFunc(fn, benchmarkRe, "B") {
+			kind, cmd = testItemBenchmark, "gnols.bench"
+		} else {
+			continue
+		}
+
+		rng := pkg.Range(fn)
+		child := testItem{
+			ID:    path + "#" + fn.Name.Name,
+			Label: fn.Name.Name,
+			Kind:  kind,
+			URI:   item.URI,
+			Range: &rng,
+		}
+		if args, err := newCommandArgs(path, fn.Name.Name); err == nil {
+			child.Command = &protocol.Command{Title: "run " + kind, Command: cmd, Arguments: []interface{}{args}}
+		}
+
+		if kind == testItemTest {
+			for _, sub := range subtests(fn) {
+				child.Children = append(child.Children, subtestItem(pkg, path, sub))
+			}
+		}
+
+		item.Children = append(item.Children, child)
+	}
+
+	if args, err := newCommandArgs(path, names...); err == nil && len(names) > 0 {
+		item.Command = &protocol.Command{Title: "run file tests", Command: "gnols.test", Arguments: []interface{}{args}}
+	}
+
+	return item
+}
+
+func subtestItem(pkg *store.Package, path string, sub subtest) testItem {
+	rng := pkg.Range(sub.Node)
+	item := testItem{
+		ID:    path + "#" + sub.Name,
+		Label: sub.Name[strings.Index(sub.Name, "/")+1:],
+		Kind:  testItemSubtest,
+		URI:   uri.File(path),
+		Range: &rng,
+	}
+	if args, err := newCommandArgs(path, sub.Name); err == nil {
+		item.Command = &protocol.Command{Title: "run subtest", Command: "gnols.test", Arguments: []interface{}{args}}
+	}
+	return item
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestDiscoverTests(t *testing.T) {
+	pkgs, err := store.NewDocumentStore().LoadWorkspace("../../testdata/discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []testItem{}
+	for _, pkg := range pkgs {
+		if item := packageTests(pkg); len(item.Children) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	if len(items) != 1 || items[0].Label != "math" {
+		t.Fatalf("expected = %v, got = %v", "math", items)
+	}
+
+	files := items[0].Children
+	if len(files) != 2 || files[0].Kind != testItemFile || files[1].Kind != testItemFiletest {
+		t.Fatalf("expected = %v, got = %v", "file, filetest", files)
+	}
+
+	tests := files[0].Children
+	if len(tests) != 2 {
+		t.Fatalf("expected = %v, got = %v", 2, len(tests))
+	}
+
+	if tests[0].Label != "TestAdd" || tests[0].Kind != testItemTest || tests[0].Range.Start.Line != 4 {
+		t.Errorf("expected = %v, got = %v", "TestAdd", tests[0])
+	}
+
+	if tests[1].Label != "BenchmarkAdd" || tests[1].Command.Command != "gnols.bench" {
+		t.Errorf("expected = %v, got = %v", "BenchmarkAdd", tests[1])
+	}
+}
+
+func TestTestTree(t *testing.T) {
+	var tree testTree
+
+	item := testItem{ID: "/p", Children: []testItem{{ID: "/p/a_test.gno"}}}
+	if tree.update(item) {
+		t.Errorf("expected = %v, got = %v", false, true) // not discovered yet
+	}
+
+	tree.reset([]testItem{item})
+	if tree.update(item) {
+		t.Errorf("expected = %v, got = %v", false, true) // unchanged
+	}
+
+	item.Children = nil
+	if !tree.update(item) {
+		t.Errorf("expected = %v, got = %v", true, false) // removed
+	}
+}
+
+func TestRefreshTests(t *testing.T) {
+	conn := &recordingConn{}
+	h := &handler{connPool: conn, documents: store.NewDocumentStore()}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a_test.gno")
+	src := "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n"
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	watched := func(typ protocol.FileChangeType) {
+		req, err := jsonrpc2.NewNotification(protocol.MethodWorkspaceDidChangeWatchedFiles, protocol.DidChangeWatchedFilesParams{
+			Changes: []*protocol.FileEvent{{Type: typ, URI: uri.File(path)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reply := func(context.Context, interface{}, error) error { return nil }
+		if err = h.handleDidChangeWatchedFiles(context.Background(), reply, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The client hasn't discovered the tests, so there's nothing to do.
+	watched(protocol.FileChangeTypeCreated)
+	if sent := conn.sent(methodTestsChanged); len(sent) != 0 {
+		t.Errorf("expected = %v, got = %s", 0, sent)
+	}
+
+	h.tests.reset(nil)
+	watched(protocol.FileChangeTypeCreated)
+	if sent := conn.sent(methodTestsChanged); len(sent) != 1 || !strings.Contains(string(sent[0]), "TestA") {
+		t.Errorf("expected = %v, got = %s", "TestA", sent)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	watched(protocol.FileChangeTypeDeleted)
+	if sent := conn.sent(methodTestsChanged); len(sent) != 2 || strings.Contains(string(sent[1]), "TestA") {
+		t.Errorf("expected = %v, got = %s", "no tests", sent)
+	}
+}
+
+func TestRefreshTestsOnClose(t *testing.T) {
+	conn := &recordingConn{}
+	h := &handler{connPool: conn, documents: store.NewDocumentStore()}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "pkg")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	src := "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "a_test.gno"), []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The client refers to the file through a symlink.
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Skip(err)
+	}
+
+	h.tests.reset(nil)
+
+	req, err := jsonrpc2.NewNotification(protocol.MethodTextDocumentDidClose, protocol.DidCloseTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri.File(filepath.Join(link, "a_test.gno"))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := func(context.Context, interface{}, error) error { return nil }
+	if err = h.handleTextDocumentDidClose(context.Background(), reply, req); err != nil {
+		t.Fatal(err)
+	}
+
+	canonical, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := conn.sent(methodTestsChanged)
+	if len(sent) != 1 || !strings.Contains(string(sent[0]), `"id":"`+canonical+`"`) {
+		t.Errorf("expected = %v, got = %s", canonical, sent)
+	}
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+// codeActionKindAddTest is the kind of the "Add test" source action.
+const codeActionKindAddTest protocol.CodeActionKind = "source.addTest"
+
+var errTestExists = errors.New("test already exists")
+
+// testFileOf returns the path of the test file for a Gno source file.
+func testFileOf(path string) string {
+	return strings.TrimSuffix(path, ".gno") + "_test.gno"
+}
+
+// isRealm reports whether the package is a realm (`gno.land/r/...`).
+func isRealm(pkg *store.Package) bool {
+	if pkg.ImportPath != "" {
+		return strings.Contains(pkg.ImportPath, "/r/")
+	}
+	return strings.Contains(filepath.ToSlash(pkg.Dir), "/r/")
+}
+
+// addTestAction returns the action that generates a test for the exported
+// function at the given position.
+//
+// If the sibling test file exists, the test is appended to it with an edit;
+// otherwise, the `gnols.generateTest` command creates the file.
+func (h *handler) addTestAction(doc *store.Document, pos protocol.Position) []protocol.CodeAction {
+	if store.IsTestFile(doc.Path) {
+		return nil
+	}
+
+	fn := exportedFuncAt(doc, pos)
+	if fn == nil {
+		return nil
+	}
+	title := fmt.Sprintf("Add test for %s", fn.Name.Name)
+
+	testPath := testFileOf(doc.Path)
+	if _, err := os.Stat(testPath); err != nil {
+		args, argsErr := newCommandArgs(doc.Path, fn.Name.Name)
+		if argsErr != nil {
+			return nil
+		}
+
+		return []protocol.CodeAction{{
+			Title: title,
+			Kind:  codeActionKindAddTest,
+			Command: &protocol.Command{
+				Title:     title,
+				Command:   "gnols.generateTest",
+				Arguments: []interface{}{args},
+			},
+		}}
+	}
+
+	testDoc, err := h.documents.LoadDocument(testPath)
+	if err != nil || testDoc.Pgf == nil {
+		return nil
+	}
+
+	edits, err := h.appendTest(doc, testDoc, fn)
+	if err != nil {
+		return nil
+	}
+
+	return []protocol.CodeAction{{
+		Title: title,
+		Kind:  codeActionKindAddTest,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri.File(testPath): edits,
+			},
+		},
+	}}
+}
+
+// exportedFuncAt returns the exported (non-method) function declared at the
+// given position.
+func exportedFuncAt(doc *store.Document, pos protocol.Position) *ast.FuncDecl {
+	for _, n := range enclosingNodes(doc.Pgf.File, doc.PositionToPos(pos)) {
+		if fn, ok := n.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.IsExported() {
+			return fn
+		}
+	}
+	return nil
+}
+
+// appendTest returns the edits that add a test for fn to the end of the
+// existing test file, along with any imports it needs.
+func (h *handler) appendTest(doc, testDoc *store.Document, fn *ast.FuncDecl) ([]protocol.TextEdit, error) {
+	name := "Test" + fn.Name.Name
+	for _, decl := range testDoc.Pgf.File.Decls {
+		if other, ok := decl.(*ast.FuncDecl); ok && other.Name.Name == name {
+			return nil, errTestExists
+		}
+	}
+
+	realm := h.realmDoc(doc)
+
+	end := testDoc.PosToPosition(testDoc.Pgf.File.End())
+	edits := addImport(testDoc, testImports(realm)...)
+	edits = append(edits, protocol.TextEdit{
+		Range:   protocol.Range{Start: end, End: end},
+		NewText: "\n\n" + testSkeleton(fn, realm),
+	})
+
+	return edits, nil
+}
+
+// generateTest creates the test file for the function in the given file.
+//
+// If the client supports it, the file is created with a workspace edit, so
+// that the client knows about it (and can undo it); otherwise, it's written
+// to disk.
+func (h *handler) generateTest(ctx context.Context, path, name string) error {
+	doc, err := h.documents.LoadDocument(path)
+	if err != nil {
+		return err
+	} else if doc.Pgf == nil {
+		return errors.New("unable to parse " + path)
+	}
+
+	var fn *ast.FuncDecl
+	for _, decl := range doc.Pgf.File.Decls {
+		if f, ok := decl.(*ast.FuncDecl); ok && f.Recv == nil && f.Name.Name == name {
+			fn = f
+		}
+	}
+
+	if fn == nil {
+		return fmt.Errorf("function %s not found", name)
+	}
+
+	testPath := testFileOf(path)
+	if _, statErr := os.Stat(testPath); statErr == nil {
+		return errTestExists
+	}
+
+	realm := h.realmDoc(doc)
+	lines := []importLine{}
+	for _, imp := range testImports(realm) {
+		lines = append(lines, importLine{Path: imp})
+	}
+
+	content := fmt.Sprintf(
+		"package %s\n\n%s\n\n%s\n",
+		doc.Pgf.File.Name.Name,
+		formatImports(lines, nil),
+		testSkeleton(fn, realm))
+
+	if !h.createFiles {
+		return os.WriteFile(testPath, []byte(content), 0o644) //nolint:gosec
+	}
+
+	// Applying an edit is a request to the client, so it can't block the
+	// command's handler.
+	go h.applyEdit(context.WithoutCancel(ctx), "Add test for "+name, createFileEdit(testPath, content))
+	return nil
+}
+
+// createFileEdit returns the workspace edit that creates a file with the
+// given content.
+func createFileEdit(path, content string) resourceEdit {
+	fileURI := uri.File(path)
+	return resourceEdit{
+		DocumentChanges: []interface{}{
+			protocol.CreateFile{Kind: protocol.CreateResourceOperation, URI: fileURI},
+			protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: fileURI},
+				},
+				Edits: []protocol.TextEdit{{NewText: content}},
+			},
+		},
+	}
+}
+
+func (h *handler) realmDoc(doc *store.Document) bool {
+	pkg, err := h.documents.LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		return false
+	}
+	return isRealm(pkg)
+}
+
+func testImports(realm bool) []string {
+	if realm {
+		return []string{"std", "testing", "gno.land/p/demo/testutils"}
+	}
+	return []string{"testing"}
+}
+
+// testSkeleton returns a table-driven test for the function.
+func testSkeleton(fn *ast.FuncDecl, realm bool) string {
+	var b strings.Builder
+
+	name := fn.Name.Name
+	fmt.Fprintf(&b, "func Test%s(t *testing.T) {\n", name)
+	if realm {
+		b.WriteString("\tcaller := testutils.TestAddress(\"caller\")\n")
+		b.WriteString("\tstd.TestSetOrigCaller(caller)\n\n")
+	}
+
+	params, args := testFields(fn.Type.Params, "arg", true)
+	results, wants := testFields(fn.Type.Results, "want", false)
+
+	b.WriteString("\ttests := []struct {\n\t\tname string\n")
+	for _, field := range append(params, results...) {
+		fmt.Fprintf(&b, "\t\t%s\n", field)
+	}
+	b.WriteString("\t}{\n\t\t// TODO: Add test cases.\n\t}\n\n")
+
+	b.WriteString("\tfor _, tt := range tests {\n")
+	b.WriteString("\t\tt.Run(tt.name, func(t *testing.T) {\n")
+
+	call := fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+	if len(wants) == 0 {
+		fmt.Fprintf(&b, "\t\t\t%s\n", call)
+	} else {
+		gots := make([]string, 0, len(wants))
+		for i := range wants {
+			gots = append(gots, strings.Replace(wants[i], "want", "got", 1))
+		}
+		fmt.Fprintf(&b, "\t\t\t%s := %s\n", strings.Join(gots, ", "), call)
+
+		for i, want := range wants {
+			if !isComparable(fn.Type.Results, i) {
+				fmt.Fprintf(&b, "\t\t\t// TODO: Compare %s and tt.%s.\n", gots[i], want)
+				continue
+			}
+			fmt.Fprintf(&b, "\t\t\tif %s != tt.%s {\n", gots[i], want)
+			fmt.Fprintf(&b, "\t\t\t\tt.Errorf(\"%s() %s = %%v, want %%v\", %s, tt.%s)\n", name, gots[i], gots[i], want)
+			b.WriteString("\t\t\t}\n")
+		}
+	}
+
+	b.WriteString("\t\t})\n\t}\n}")
+	return b.String()
+}
+
+// testFields returns the struct fields (`arg int`) for a parameter or
+// result list, along with the expressions that refer to them (`tt.arg`, or
+// just `want` for results).
+func testFields(list *ast.FieldList, base string, params bool) ([]string, []string) {
+	fields, refs := []string{}, []string{}
+	if list == nil {
+		return fields, refs
+	}
+
+	i := 0
+	for _, field := range list.List {
+		names := []string{}
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, "")
+		}
+
+		for _, n := range names {
+			if !params || n == "" || n == "_" {
+				n = base
+				if i > 0 {
+					n = fmt.Sprintf("%s%d", base, i)
+				}
+			} else if n == "name" {
+				n = "nameArg" // taken by the test case's name
+			}
+			i++
+
+			typ := field.Type
+			ref := "tt." + n
+			if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+				typ = &ast.ArrayType{Elt: ellipsis.Elt}
+				ref += "..."
+			}
+
+			fields = append(fields, n+" "+exprString(typ))
+			if params {
+				refs = append(refs, ref)
+			} else {
+				refs = append(refs, n)
+			}
+		}
+	}
+
+	return fields, refs
+}
+
+// isComparable reports whether the i-th result can be compared with `!=`.
+func isComparable(results *ast.FieldList, i int) bool {
+	for _, field := range results.List {
+		n := max(len(field.Names), 1)
+		if i < n {
+			switch field.Type.(type) {
+			case *ast.ArrayType, *ast.MapType, *ast.FuncType:
+				return false
+			default:
+				return true
+			}
+		}
+		i -= n
+	}
+	return false
+}
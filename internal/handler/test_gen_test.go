@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestGenerateTest(t *testing.T) {
+	dir := t.TempDir()
+
+	mod := "module gno.land/r/demo/greeter\n"
+	if err := os.WriteFile(filepath.Join(dir, "gno.mod"), []byte(mod), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	src := `package greeter
+
+func Greet(name string, times int) (string, error) {
+	return "", nil
+}
+`
+	path := filepath.Join(dir, "greeter.gno")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &handler{documents: store.NewDocumentStore()}
+	if err := h.generateTest(context.Background(), path, "Greet"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "greeter_test.gno"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"package greeter",
+		"\"gno.land/p/demo/testutils\"",
+		"std.TestSetOrigCaller(caller)",
+		"func TestGreet(t *testing.T) {",
+		"\t\tnameArg string\n\t\ttimes int\n\t\twant string\n\t\twant1 error\n",
+		"got, got1 := Greet(tt.nameArg, tt.times)",
+		"if got1 != tt.want1 {",
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(got), e) {
+			t.Errorf("expected = %v, got = %v", e, string(got))
+		}
+	}
+
+	// The test now exists, so we don't overwrite it.
+	if err = h.generateTest(context.Background(), path, "Greet"); !errors.Is(err, errTestExists) {
+		t.Errorf("expected = %v, got = %v", errTestExists, err)
+	}
+}
+
+func TestCreateFileEdit(t *testing.T) {
+	data, err := json.Marshal(createFileEdit("/tmp/greeter_test.gno", "package greeter\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"documentChanges":[` +
+		`{"kind":"create","uri":"file:///tmp/greeter_test.gno"},` +
+		`{"textDocument":{"uri":"file:///tmp/greeter_test.gno","version":null},` +
+		`"edits":[{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}},"newText":"package greeter\n"}]}]}`
+	if string(data) != expected {
+		t.Errorf("expected = %v, got = %v", expected, string(data))
+	}
+}
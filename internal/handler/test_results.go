@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/gno"
+)
+
+// methodTestResults is the notification sent after each test run, so that
+// editor plugins can render a test tree.
+const methodTestResults = "gnols/testResults"
+
+// testResultsParams are the parameters of `gnols/testResults`.
+type testResultsParams struct {
+	Package string           `json:"package"`
+	Tests   []string         `json:"tests"`
+	Passed  bool             `json:"passed"`
+	Results []gno.TestResult `json:"results"`
+	Output  string           `json:"output"`
+}
+
+// diagnosticCache holds the published diagnostics, so that build, test and
+// coverage diagnostics can be published together.
+type diagnosticCache struct {
+	mu       sync.Mutex
+	build    map[string][]protocol.Diagnostic
+	tests    map[string][]protocol.Diagnostic
+	coverage map[string][]protocol.Diagnostic
+}
+
+// all returns every diagnostic of a file; the lock must be held.
+func (c *diagnosticCache) all(path string) []protocol.Diagnostic {
+	diags := append([]protocol.Diagnostic{}, c.build[path]...)
+	diags = append(diags, c.tests[path]...)
+	return append(diags, c.coverage[path]...)
+}
+
+// setBuild records the build diagnostics of a file, returning all of its
+// diagnostics.
+func (c *diagnosticCache) setBuild(path string, diags []protocol.Diagnostic) []protocol.Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.build == nil {
+		c.build = map[string][]protocol.Diagnostic{}
+	}
+	c.build[path] = diags
+
+	return c.all(path)
+}
+
+// setTests replaces the test diagnostics of the package's files, returning
+// all of the diagnostics of every file that changed.
+func (c *diagnosticCache) setTests(pkg string, diags map[string][]protocol.Diagnostic) map[string][]protocol.Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tests == nil {
+		c.tests = map[string][]protocol.Diagnostic{}
+	}
+	return c.replace(c.tests, func(path string) bool { return filepath.Dir(path) == pkg }, diags)
+}
+
+// setFileTests replaces the test diagnostics of a single file, returning
+// all of its diagnostics.
+func (c *diagnosticCache) setFileTests(path string, diags []protocol.Diagnostic) []protocol.Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tests == nil {
+		c.tests = map[string][]protocol.Diagnostic{}
+	}
+	c.tests[path] = diags
+
+	return c.all(path)
+}
+
+// setCoverage replaces all of the coverage diagnostics, returning all of the
+// diagnostics of every file that changed.
+func (c *diagnosticCache) setCoverage(diags map[string][]protocol.Diagnostic) map[string][]protocol.Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.coverage == nil {
+		c.coverage = map[string][]protocol.Diagnostic{}
+	}
+	return c.replace(c.coverage, func(string) bool { return true }, diags)
+}
+
+// replace removes the entries of m that match, adds the given ones, and
+// returns all of the diagnostics of every file that changed; the lock must
+// be held.
+func (c *diagnosticCache) replace(m map[string][]protocol.Diagnostic, match func(string) bool, diags map[string][]protocol.Diagnostic) map[string][]protocol.Diagnostic {
+	changed := map[string][]protocol.Diagnostic{}
+	for path := range m {
+		if match(path) {
+			delete(m, path)
+			changed[path] = nil
+		}
+	}
+
+	for path, d := range diags {
+		m[path] = d
+		changed[path] = nil
+	}
+
+	for path := range changed {
+		changed[path] = c.all(path)
+	}
+	return changed
+}
+
+// progress reports the progress of a long-running operation with
+// `$/progress`, if the client supports it.
+type progress struct {
+	h     *handler
+	token *protocol.ProgressToken
+}
+
+// beginProgress starts reporting progress, using the client's token or
+// creating one.
+func (h *handler) beginProgress(ctx context.Context, token *protocol.ProgressToken, title string) *progress {
+	if token == nil {
+		if !h.workDoneProgress {
+			return &progress{h: h}
+		}
+
+		token = protocol.NewProgressToken(fmt.Sprintf("gnols-%d", h.progressID.Add(1)))
+
+		_, err := h.connPool.Call(ctx, protocol.MethodWorkDoneProgressCreate, protocol.WorkDoneProgressCreateParams{
+			Token: *token,
+		}, nil)
+		if err != nil {
+			slog.Warn("progress", "error", err)
+			return &progress{h: h}
+		}
+	}
+
+	p := &progress{h: h, token: token}
+	p.notify(ctx, protocol.WorkDoneProgressBegin{
+		Kind:  protocol.WorkDoneProgressKindBegin,
+		Title: title,
+	})
+	return p
+}
+
+func (p *progress) report(ctx context.Context, msg string) {
+	p.notify(ctx, protocol.WorkDoneProgressReport{
+		Kind:    protocol.WorkDoneProgressKindReport,
+		Message: msg,
+	})
+}
+
+func (p *progress) end(ctx context.Context, msg string) {
+	p.notify(ctx, protocol.WorkDoneProgressEnd{
+		Kind:    protocol.WorkDoneProgressKindEnd,
+		Message: msg,
+	})
+}
+
+func (p *progress) notify(ctx context.Context, value interface{}) {
+	if p.token == nil {
+		return
+	}
+
+	err := p.h.connPool.Notify(ctx, protocol.MethodProgress, protocol.ProgressParams{
+		Token: *p.token,
+		Value: value,
+	})
+	if err != nil {
+		slog.Warn("progress", "error", err)
+	}
+}
+
+// testSettings controls how tests (and benchmarks) are run.
+type testSettings struct {
+	Options gno.TestOptions
+	Verbose bool // whether to log the output of test runs to the client
+}
+
+// logOutput sends the output of a test run to the client's log, if the
+// user asked for it.
+func (h *handler) logOutput(ctx context.Context, settings testSettings, output string) {
+	if settings.Verbose && output != "" {
+		h.notify(ctx, protocol.MethodWindowLogMessage, protocol.LogMessageParams{
+			Type:    protocol.MessageTypeLog,
+			Message: output,
+		})
+	}
+}
+
+// runTest runs the test(s), streaming their progress and then reporting
+// their results as a message, diagnostics and a `gnols/testResults`
+// notification (followed by their coverage, if enabled).
+func (h *handler) runTest(ctx context.Context, pkg string, tests []string, settings testSettings, token *protocol.ProgressToken) {
+	slog.Info("execute_command", "pkg", pkg, "tests", tests)
+
+	opts := settings.Options
+	opts.CoverProfile = h.coverProfile()
+
+	p := h.beginProgress(ctx, token, "Running tests")
+	out, err := h.binManager.StreamTest(pkg, tests, opts, func(line string) {
+		h.logOutput(ctx, settings, line)
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "=== RUN") || strings.HasPrefix(trimmed, "--- ") {
+			p.report(ctx, trimmed)
+		}
+	})
+	slog.Info("execute_command", "out", string(out))
+
+	results := gno.ParseTestOutput(string(out))
+	passed := err == nil
+
+	summary := testSummary(results, passed)
+	if errors.Is(err, gno.ErrNoCoverage) {
+		// The tests didn't run at all, so there's no coverage to show.
+		os.Remove(opts.CoverProfile)
+		opts.CoverProfile = ""
+		summary = "FAIL: " + err.Error() + "; disable coverage to run the tests"
+	}
+	p.end(ctx, summary)
+
+	msgType := protocol.MessageTypeInfo
+	if !passed {
+		msgType = protocol.MessageTypeError
+	}
+	h.notify(ctx, protocol.MethodWindowShowMessage, protocol.ShowMessageParams{
+		Type:    msgType,
+		Message: summary,
+	})
+
+	h.publishDiagnostics(ctx, h.diagnostics.setTests(pkg, h.testDiagnostics(pkg, results)))
+	if opts.CoverProfile != "" {
+		h.publishCoverage(ctx, pkg, opts.CoverProfile)
+	}
+
+	h.notify(ctx, methodTestResults, testResultsParams{
+		Package: pkg,
+		Tests:   tests,
+		Passed:  passed,
+		Results: results,
+		Output:  string(out),
+	})
+}
+
+func (h *handler) notify(ctx context.Context, method string, params interface{}) {
+	if err := h.connPool.Notify(ctx, method, params); err != nil {
+		slog.Error("notify", "method", method, "error", err)
+	}
+}
+
+// testSummary returns a one-line summary of a test run.
+func testSummary(results []gno.TestResult, passed bool) string {
+	counts := map[string]int{}
+	failed := []string{}
+	for _, r := range results {
+		counts[r.Status]++
+		if r.Status == "fail" {
+			failed = append(failed, r.Name)
+		}
+	}
+
+	if passed {
+		return fmt.Sprintf("PASS: %d passed, %d skipped", counts["pass"], counts["skip"])
+	} else if len(failed) == 0 {
+		return "FAIL: the tests couldn't be run"
+	}
+	return fmt.Sprintf("FAIL: %d passed, %d failed (%s)", counts["pass"], len(failed), strings.Join(failed, ", "))
+}
+
+// testDiagnostics converts the logged failures into diagnostics, by file.
+func (h *handler) testDiagnostics(pkg string, results []gno.TestResult) map[string][]protocol.Diagnostic {
+	diags := map[string][]protocol.Diagnostic{}
+
+	for _, r := range results {
+		for _, f := range r.Failures {
+			path := filepath.Join(pkg, filepath.Base(f.File))
+			line := uint32(max(f.Line-1, 0))
+
+			end := protocol.Position{Line: line + 1}
+			if doc, err := h.documents.LoadDocument(path); err == nil && int(line) < len(doc.Lines) {
+				end = lineEnd(doc, int(line))
+			}
+
+			diags[path] = append(diags[path], protocol.Diagnostic{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: line},
+					End:   end,
+				},
+				Severity: protocol.DiagnosticSeverityError,
+				Source:   "gnols",
+				Code:     "test",
+				Message:  fmt.Sprintf("%s: %s", r.Name, f.Msg),
+			})
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/gno"
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestTestDiagnostics(t *testing.T) {
+	pkg, err := filepath.Abs("../../testdata/imports")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &handler{documents: store.NewDocumentStore()}
+	diags := h.testDiagnostics(pkg, []gno.TestResult{{
+		Name:   "TestRender",
+		Status: "fail",
+		Failures: []gno.TestFailure{
+			{File: "gno.land/r/demo/unused.gno", Line: 10, Msg: "bad render"},
+		},
+	}})
+
+	path := filepath.Join(pkg, "unused.gno")
+	if len(diags[path]) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, diags)
+	}
+
+	d := diags[path][0]
+	if d.Message != "TestRender: bad render" {
+		t.Errorf("expected = %v, got = %v", "TestRender: bad render", d.Message)
+	}
+
+	// func Render(path string) string {
+	expected := protocol.Range{
+		Start: protocol.Position{Line: 9},
+		End:   protocol.Position{Line: 9, Character: 33},
+	}
+	if d.Range != expected {
+		t.Errorf("expected = %v, got = %v", expected, d.Range)
+	}
+}
+
+func TestDiagnosticCache(t *testing.T) {
+	var c diagnosticCache
+
+	build := []protocol.Diagnostic{{Message: "build"}}
+	test := []protocol.Diagnostic{{Message: "test"}}
+
+	c.setBuild("/p/a.gno", build)
+	changed := c.setTests("/p", map[string][]protocol.Diagnostic{"/p/a_test.gno": test})
+	if len(changed) != 1 || len(changed["/p/a_test.gno"]) != 1 {
+		t.Errorf("expected = %v, got = %v", 1, changed)
+	}
+
+	if all := c.setBuild("/p/a_test.gno", build); len(all) != 2 {
+		t.Errorf("expected = %v, got = %v", 2, len(all))
+	}
+
+	// A passing run clears the file's test diagnostics.
+	changed = c.setTests("/p", nil)
+	if len(changed["/p/a_test.gno"]) != 1 || changed["/p/a_test.gno"][0].Message != "build" {
+		t.Errorf("expected = %v, got = %v", build, changed)
+	}
+}
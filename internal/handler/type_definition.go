@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func (h *handler) handleTypeDefinition(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.TypeDefinitionParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	pkg, err := h.documents.LoadPackage(filepath.Dir(doc.Path))
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	locations := []protocol.Location{}
+
+	_, id, _ := pkgIdentAt(pkg, doc.Path, docOffset(doc, params.Position))
+	if id == nil {
+		return reply(ctx, locations, nil)
+	}
+
+	keys := typeKeysOf(pkg, id)
+	if len(keys) == 0 {
+		return reply(ctx, locations, nil)
+	}
+
+	idx, err := h.typeIndex(doc.Path)
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	for _, key := range keys {
+		if t, found := idx.lookup(key); found {
+			if loc := t.location(""); loc != nil {
+				locations = append(locations, *loc)
+			}
+		}
+	}
+
+	return reply(ctx, locations, nil)
+}
+
+// typeKeysOf returns the named types that make up the type of the given
+// identifier (e.g., `Tree` for `*avl.Tree` or `Key` and `Value` for
+// `map[Key]Value`).
+//
+// We prefer the type checker's result, but since Gno-only packages can't be
+// type-checked, we fall back to the identifier's declaration.
+func typeKeysOf(pkg *store.Package, id *ast.Ident) []typeKey {
+	ti := pkg.TypeInfo()
+	if ti.Pkg == nil {
+		return nil
+	}
+
+	obj := ti.ObjectOf(id)
+	if obj == nil {
+		return nil
+	}
+
+	if keys := namedKeys(pkg, ti, obj.Type()); len(keys) > 0 {
+		return keys
+	}
+
+	file := pkg.Files[pkg.FileOf(obj.Pos())]
+	if file == nil {
+		return nil
+	}
+
+	expr := declTypeExpr(pkg, ti, file, obj.Pos())
+	if expr == nil {
+		return nil
+	}
+
+	return exprKeys(pkg, file, expr)
+}
+
+// namedKeys returns the named types within a resolved type.
+func namedKeys(pkg *store.Package, ti *store.TypeInfo, t types.Type) []typeKey {
+	switch typ := t.(type) {
+	case *types.Pointer:
+		return namedKeys(pkg, ti, typ.Elem())
+	case *types.Slice:
+		return namedKeys(pkg, ti, typ.Elem())
+	case *types.Array:
+		return namedKeys(pkg, ti, typ.Elem())
+	case *types.Chan:
+		return namedKeys(pkg, ti, typ.Elem())
+	case *types.Map:
+		keys := namedKeys(pkg, ti, typ.Key())
+		return append(keys, namedKeys(pkg, ti, typ.Elem())...)
+	case *types.Named:
+		obj := typ.Obj()
+		if obj.Pkg() == nil {
+			return nil // `error`
+		}
+
+		path := obj.Pkg().Path()
+		if obj.Pkg() == ti.Pkg {
+			path = packageDetail(pkg)
+		}
+		return []typeKey{{Pkg: path, Name: obj.Name()}}
+	default:
+		return nil
+	}
+}
+
+// declTypeExpr returns the type expression of the variable (or field)
+// declared at the given position: either its declared type or, for `:=`
+// and untyped `var` declarations, the type of its value.
+func declTypeExpr(pkg *store.Package, ti *store.TypeInfo, file *ast.File, pos token.Pos) ast.Expr {
+	nodes := enclosingNodes(file, pos)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		switch node := nodes[i].(type) {
+		case *ast.Field:
+			return node.Type
+		case *ast.ValueSpec:
+			if node.Type != nil {
+				return node.Type
+			}
+			return valueTypeExpr(pkg, ti, file, identsAt(node.Names, pos), node.Values)
+		case *ast.AssignStmt:
+			return valueTypeExpr(pkg, ti, file, exprsAt(node.Lhs, pos), node.Rhs)
+		case *ast.RangeStmt, *ast.FuncDecl, *ast.BlockStmt:
+			return nil
+		}
+	}
+	return nil
+}
+
+// valueTypeExpr returns the type expression of the i-th value assigned by a
+// declaration.
+func valueTypeExpr(pkg *store.Package, ti *store.TypeInfo, file *ast.File, i int, values []ast.Expr) ast.Expr {
+	if i < 0 || len(values) == 0 {
+		return nil
+	}
+
+	value := values[0]
+	if len(values) > 1 {
+		if i >= len(values) {
+			return nil
+		}
+		value, i = values[i], 0
+	}
+
+	switch v := value.(type) {
+	case *ast.CompositeLit:
+		return v.Type
+	case *ast.UnaryExpr:
+		if lit, ok := v.X.(*ast.CompositeLit); ok && v.Op == token.AND {
+			return lit.Type
+		}
+	case *ast.CallExpr:
+		results := indexedResultTypes(file, v)
+		if len(results) == 0 {
+			results = localResultTypes(pkg, ti, v)
+		}
+		if i < len(results) {
+			return results[i]
+		}
+	}
+
+	return nil
+}
+
+// localResultTypes returns the result type expressions of a call to a
+// function declared in the package.
+func localResultTypes(pkg *store.Package, ti *store.TypeInfo, call *ast.CallExpr) []ast.Expr {
+	id := calleeIdent(call.Fun)
+	if id == nil {
+		return nil
+	}
+
+	fn, ok := ti.ObjectOf(id).(*types.Func)
+	if !ok || fn.Pkg() != ti.Pkg {
+		return nil
+	}
+
+	file := pkg.Files[pkg.FileOf(fn.Pos())]
+	if file == nil {
+		return nil
+	}
+
+	results := []ast.Expr{}
+	for _, node := range enclosingNodes(file, fn.Pos()) {
+		decl, isFunc := node.(*ast.FuncDecl)
+		if !isFunc || decl.Type.Results == nil {
+			continue
+		}
+
+		for _, field := range decl.Type.Results.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for j := 0; j < n; j++ {
+				results = append(results, field.Type)
+			}
+		}
+	}
+
+	return results
+}
+
+// exprKeys returns the named types within a type expression, as written in
+// the given file.
+func exprKeys(pkg *store.Package, file *ast.File, expr ast.Expr) []typeKey {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return exprKeys(pkg, file, e.X)
+	case *ast.ParenExpr:
+		return exprKeys(pkg, file, e.X)
+	case *ast.ArrayType:
+		return exprKeys(pkg, file, e.Elt)
+	case *ast.Ellipsis:
+		return exprKeys(pkg, file, e.Elt)
+	case *ast.ChanType:
+		return exprKeys(pkg, file, e.Value)
+	case *ast.MapType:
+		keys := exprKeys(pkg, file, e.Key)
+		return append(keys, exprKeys(pkg, file, e.Value)...)
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			return importedKey(file, x.Name, e.Sel.Name)
+		}
+	case *ast.Ident:
+		// Names qualified by `qualifyExpr` (e.g., `avl.Tree`).
+		if x, name, found := strings.Cut(e.Name, "."); found {
+			return importedKey(file, x, name)
+		} else if types.Universe.Lookup(e.Name) == nil {
+			return []typeKey{{Pkg: packageDetail(pkg), Name: e.Name}}
+		}
+	}
+	return nil
+}
+
+func importedKey(file *ast.File, pkgName, name string) []typeKey {
+	specs := importsNamed(file, pkgName)
+	if len(specs) == 0 {
+		return nil
+	}
+
+	path, err := strconv.Unquote(specs[0].Path.Value)
+	if err != nil {
+		return nil
+	}
+	return []typeKey{{Pkg: path, Name: name}}
+}
+
+// identsAt returns the index of the identifier at the given position.
+func identsAt(ids []*ast.Ident, pos token.Pos) int {
+	for i, id := range ids {
+		if id.Pos() == pos {
+			return i
+		}
+	}
+	return -1
+}
+
+// exprsAt returns the index of the expression at the given position.
+func exprsAt(exprs []ast.Expr, pos token.Pos) int {
+	for i, expr := range exprs {
+		if expr.Pos() == pos {
+			return i
+		}
+	}
+	return -1
+}
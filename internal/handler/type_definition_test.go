@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jdkato/gnols/internal/store"
+)
+
+func TestTypeKeysOf(t *testing.T) {
+	dir, err := filepath.Abs("../../testdata/type_definition/r/board")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := store.NewDocumentStore().LoadPackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "board.gno")
+
+	avlTree := typeKey{Pkg: "gno.land/p/demo/avl", Name: "Tree"}
+	post := typeKey{Pkg: "gno.land/r/demo/board", Name: "Post"}
+	board := typeKey{Pkg: "gno.land/r/demo/board", Name: "Board"}
+
+	cases := []struct {
+		ident    string
+		expected []typeKey
+	}{
+		{"tree.Set", []typeKey{avlTree}},                         // indexed call
+		{"b.Index", []typeKey{board}},                            // local call
+		{"Index.Size", []typeKey{avlTree}},                       // struct field
+		{"post)", []typeKey{post}},                               // composite literal
+		{"boards []", []typeKey{board}},                          // slice
+		{"Posts map", []typeKey{post}},                           // map (of builtin keys)
+		{"Author std", []typeKey{{Pkg: "std", Name: "Address"}}}, // imported
+	}
+
+	src := pkg.Sources[path]
+	for _, c := range cases {
+		offset := strings.Index(src, c.ident)
+		if offset < 0 {
+			t.Fatalf("missing %q", c.ident)
+		}
+
+		_, id, _ := pkgIdentAt(pkg, path, offset)
+		if id == nil {
+			t.Fatalf("no identifier at %q", c.ident)
+		}
+
+		keys := typeKeysOf(pkg, id)
+		if len(keys) != len(c.expected) || keys[0] != c.expected[0] {
+			t.Errorf("%s: expected = %v, got = %v", c.ident, c.expected, keys)
+		}
+	}
+}
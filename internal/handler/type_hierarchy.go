@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// NOTE: Type hierarchies were added in LSP 3.17, so they're not part of
+// `go.lsp.dev/protocol`.
+const (
+	methodTextDocumentPrepareTypeHierarchy = "textDocument/prepareTypeHierarchy"
+	methodTypeHierarchySupertypes          = "typeHierarchy/supertypes"
+	methodTypeHierarchySubtypes            = "typeHierarchy/subtypes"
+)
+
+type typeHierarchyItem struct {
+	Name           string               `json:"name"`
+	Kind           protocol.SymbolKind  `json:"kind"`
+	Detail         string               `json:"detail,omitempty"`
+	URI            protocol.DocumentURI `json:"uri"`
+	Range          protocol.Range       `json:"range"`
+	SelectionRange protocol.Range       `json:"selectionRange"`
+	Data           typeKey              `json:"data"`
+}
+
+type typeHierarchyItemParams struct {
+	Item typeHierarchyItem `json:"item"`
+}
+
+func (h *handler) handlePrepareTypeHierarchy(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	var params protocol.TextDocumentPositionParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	doc, ok := h.documents.Get(params.TextDocument.URI)
+	if !ok {
+		return noDocFound(ctx, reply, params.TextDocument.URI)
+	}
+
+	idx, key, _, err := h.typeAt(doc, params.Position)
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	t, found := idx.lookup(key)
+	if !found {
+		return reply(ctx, nil, nil)
+	}
+
+	item, found := typeItem(t)
+	if !found {
+		return reply(ctx, nil, nil)
+	}
+
+	return reply(ctx, []typeHierarchyItem{item}, nil)
+}
+
+func (h *handler) handleSupertypes(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	return h.relatedTypes(ctx, reply, req, (*typeIndex).interfacesOf)
+}
+
+func (h *handler) handleSubtypes(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	return h.relatedTypes(ctx, reply, req, func(idx *typeIndex, t namedType) []namedType {
+		if !t.Iface {
+			return []namedType{}
+		}
+		return idx.implementations(t)
+	})
+}
+
+// relatedTypes replies with the items for the types related to the request's
+// item.
+func (h *handler) relatedTypes(
+	ctx context.Context,
+	reply jsonrpc2.Replier,
+	req jsonrpc2.Request,
+	related func(*typeIndex, namedType) []namedType,
+) error {
+	var params typeHierarchyItemParams
+
+	if req.Params() == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.InvalidParams}
+	} else if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return badJSON(ctx, reply, err)
+	}
+
+	idx, err := h.typeIndex(uri.URI(params.Item.URI).Filename())
+	if err != nil {
+		return reply(ctx, nil, err)
+	}
+
+	items := []typeHierarchyItem{}
+
+	t, found := idx.lookup(params.Item.Data)
+	if !found {
+		return reply(ctx, items, nil)
+	}
+
+	for _, other := range related(idx, t) {
+		if item, ok := typeItem(other); ok {
+			items = append(items, item)
+		}
+	}
+
+	return reply(ctx, items, nil)
+}
+
+// typeItem returns the hierarchy item for a type; types without a known
+// location (such as most of the indexed ones) have no item.
+func typeItem(t namedType) (typeHierarchyItem, bool) {
+	loc := t.location("")
+	if loc == nil {
+		return typeHierarchyItem{}, false
+	}
+
+	kind := protocol.SymbolKindStruct
+	if t.Iface {
+		kind = protocol.SymbolKindInterface
+	}
+
+	return typeHierarchyItem{
+		Name:           t.Key.Name,
+		Kind:           kind,
+		Detail:         t.Key.Pkg,
+		URI:            loc.URI,
+		Range:          loc.Range,
+		SelectionRange: loc.Range,
+		Data:           t.Key,
+	}, true
+}
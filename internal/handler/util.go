@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"context"
 	"go/ast"
+	"log/slog"
 	"strings"
 
 	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
 
 	"github.com/jdkato/gnols/internal/stdlib"
+	"github.com/jdkato/gnols/internal/store"
 )
 
 func posToRange(line int, span []int) *protocol.Range {
@@ -22,6 +26,109 @@ func posToRange(line int, span []int) *protocol.Range {
 	}
 }
 
+// resourceEdit is a workspace edit whose document changes can include
+// resource operations (e.g., `protocol.CreateFile`), which
+// `protocol.WorkspaceEdit` can't hold.
+type resourceEdit struct {
+	DocumentChanges []interface{} `json:"documentChanges"`
+}
+
+type applyEditParams struct {
+	Label string      `json:"label,omitempty"`
+	Edit  interface{} `json:"edit"` // protocol.WorkspaceEdit | resourceEdit
+}
+
+// applyEdit asks the client to apply the workspace edit, logging why it
+// didn't.
+func (h *handler) applyEdit(ctx context.Context, label string, edit interface{}) bool {
+	var result protocol.ApplyWorkspaceEditResponse
+
+	_, err := h.connPool.Call(ctx, protocol.MethodWorkspaceApplyEdit, applyEditParams{
+		Label: label,
+		Edit:  edit,
+	}, &result)
+	if err != nil {
+		slog.Error("apply_edit", "label", label, "error", err)
+		return false
+	} else if !result.Applied {
+		slog.Warn("apply_edit", "label", label, "reason", result.FailureReason)
+	}
+
+	return result.Applied
+}
+
+// rootFromParams returns the workspace root sent by the client, preferring
+// the first workspace folder.
+func rootFromParams(params protocol.InitializeParams) string {
+	if len(params.WorkspaceFolders) > 0 {
+		return uri.URI(params.WorkspaceFolders[0].URI).Filename()
+	} else if params.RootURI != "" {
+		return params.RootURI.Filename()
+	}
+	return params.RootPath //nolint:staticcheck
+}
+
+// identAt returns the identifier at the given position and, if the
+// identifier is part of one, its enclosing selector expression.
+func identAt(doc *store.Document, pos protocol.Position) (*ast.Ident, *ast.SelectorExpr) {
+	if doc.Pgf == nil || doc.Pgf.FileSet == nil {
+		return nil, nil
+	}
+
+	var sel *ast.SelectorExpr
+	for _, n := range enclosingNodes(doc.Pgf.File, doc.PositionToPos(pos)) {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			sel = node
+		case *ast.Ident:
+			if sel != nil && sel.Sel != node && sel.X != node {
+				sel = nil
+			}
+			return node, sel
+		}
+	}
+
+	return nil, nil
+}
+
+// pkgIdentAt returns the identifier at the given offset of a package file,
+// along with the file and, if the identifier is part of one, its enclosing
+// selector expression.
+func pkgIdentAt(pkg *store.Package, path string, offset int) (*ast.File, *ast.Ident, *ast.SelectorExpr) {
+	file, ok := pkg.Files[path]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	tf := pkg.FileSet.File(file.Pos())
+	if tf == nil || offset > tf.Size() {
+		return file, nil, nil
+	}
+
+	var sel *ast.SelectorExpr
+	for _, n := range enclosingNodes(file, tf.Pos(offset)) {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			sel = node
+		case *ast.Ident:
+			if sel != nil && sel.Sel != node && sel.X != node {
+				sel = nil
+			}
+			return file, node, sel
+		}
+	}
+
+	return file, nil, nil
+}
+
+// docOffset converts a position in the document to a byte offset.
+func docOffset(doc *store.Document, pos protocol.Position) int {
+	if p := doc.PositionToPos(pos); p.IsValid() {
+		return doc.Pgf.FileSet.Position(p).Offset
+	}
+	return len(doc.Content)
+}
+
 func lookupSymbol(pkg, symbol string) *stdlib.Symbol {
 	for _, p := range stdlib.Packages {
 		if p.Name == pkg {
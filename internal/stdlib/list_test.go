@@ -1,6 +1,9 @@
 package stdlib
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestList(t *testing.T) {
 	t.Logf("%d packages:", len(Packages))
@@ -11,3 +14,57 @@ func TestList(t *testing.T) {
 		}
 	}
 }
+
+// unindexed lists the interfaces in stdlib.gob that were indexed before
+// cmd/gen recorded method sets (and expanded embedded interfaces). Remove
+// them once stdlib.gob is regenerated.
+var unindexed = map[string]bool{
+	"gno.land/p/demo/flow.Limiter":                       true,
+	"gno.land/p/demo/gnode.Gnode":                        true,
+	"gno.land/p/demo/grc/exts.TokenMetadata":             true,
+	"gno.land/p/demo/grc/exts/vault.Vault":               true,
+	"gno.land/p/demo/grc/grc777.IGRC777":                 true,
+	"gno.land/p/demo/groups.VoteSet":                     true,
+	"gno.land/p/demo/merkle.Hashable":                    true,
+	"gno.land/p/demo/svg.Elem":                           true,
+	"gno.land/p/demo/testutils.PrivateInterface":         true,
+	"gno.land/p/demo/ui.DomStringer":                     true,
+	"gno.land/r/demo/tests.Stringer":                     true,
+	"gno.land/r/x/nir1218_evaluation_proposal.Evaluator": true,
+	"gno.land/r/x/nir1218_evaluation_proposal.Tally":     true,
+	"io.ReadWriter":                                      true,
+	"io.ReadCloser":                                      true,
+	"io.WriteCloser":                                     true,
+	"io.ReadWriteCloser":                                 true,
+	"io.ReadSeeker":                                      true,
+	"io.ReadSeekCloser":                                  true,
+	"io.WriteSeeker":                                     true,
+	"io.ReadWriteSeeker":                                 true,
+	"std.AddressSet":                                     true,
+	"std.Banker":                                         true,
+	"stdshim.AddressSet":                                 true,
+	"stdshim.Banker":                                     true,
+}
+
+func TestIndexedMethods(t *testing.T) {
+	for _, pkg := range Packages {
+		for _, sym := range pkg.Symbols {
+			if sym.Kind == "func" && strings.HasPrefix(sym.Signature, "func (") && sym.Recv == "" {
+				t.Errorf("expected a receiver for %s.%s", pkg.ImportPath, sym.Name)
+			}
+
+			if sym.Kind != "interface" {
+				continue
+			}
+
+			name := pkg.ImportPath + "." + sym.Name
+			if unindexed[name] {
+				if len(sym.Methods) > 0 {
+					t.Errorf("%s has methods now; remove it from `unindexed`", name)
+				}
+			} else if len(sym.Methods) == 0 {
+				t.Errorf("expected methods for %s", name)
+			}
+		}
+	}
+}
@@ -5,13 +5,24 @@ import (
 	_ "embed"
 	"encoding/gob"
 	"fmt"
+	"regexp"
 )
 
+var recvRe = regexp.MustCompile(`^func\s*\(\s*(?:\w+\s+)?\*?\s*(\w+)`)
+
 type Symbol struct {
 	Name      string
 	Doc       string
 	Signature string
 	Kind      string
+	Recv      string   // the receiver's type, for methods (e.g., `*Tree`)
+	Methods   []Method // the method set, for interfaces
+}
+
+// A Method is a single method of an interface.
+type Method struct {
+	Name      string
+	Signature string // e.g., `Transfer(to std.Address, amount uint64) error`
 }
 
 type Package struct {
@@ -40,6 +51,42 @@ func init() {
 	}
 }
 
+// Receiver returns the name of the method's receiver type (without the
+// pointer), or an empty string if the symbol isn't a method.
+//
+// NOTE: Older indexes don't record `Recv`, so we fall back to parsing the
+// signature.
+func (s Symbol) Receiver() string {
+	if s.Kind != "func" {
+		return ""
+	}
+
+	recv := s.Recv
+	if recv == "" {
+		m := recvRe.FindStringSubmatch(s.Signature)
+		if m == nil {
+			return ""
+		}
+		recv = m[1]
+	}
+
+	if recv[0] == '*' {
+		return recv[1:]
+	}
+	return recv
+}
+
+// MethodsOf returns the methods declared on the given type.
+func (p Package) MethodsOf(typeName string) []Symbol {
+	methods := []Symbol{}
+	for _, s := range p.Symbols {
+		if s.Receiver() == typeName {
+			methods = append(methods, s)
+		}
+	}
+	return methods
+}
+
 func (s Symbol) String() string {
 	return fmt.Sprintf("```go\n%s\n```\n\n%s", s.Signature, s.Doc)
 }
@@ -2,11 +2,8 @@ package store
 
 import (
 	"go/ast"
-	"go/importer"
 	"go/parser"
 	"go/token"
-	"go/types"
-	"log/slog"
 	"strings"
 
 	"github.com/jdkato/gnols/internal/stdlib"
@@ -16,45 +13,42 @@ import (
 type ParsedGnoFile struct {
 	File    *ast.File
 	FileSet *token.FileSet
+
+	// Err is the error the file failed to parse with, if any, in which
+	// case File is partial (but its positions still match the content).
+	Err error
 }
 
 // NewParsedGnoFile parses the Gno file with the standard parser, including
 // comments.
+//
+// If the file doesn't parse, it returns the partial AST along with the
+// error, unless not even the package clause could be parsed.
 func NewParsedGnoFile(path, content string) (*ParsedGnoFile, error) {
 	fset := token.NewFileSet()
 
 	file, err := parser.ParseFile(fset, path, content, parser.ParseComments)
-	if err != nil {
+	if file == nil || !file.Package.IsValid() {
 		return nil, err
 	}
 
-	return &ParsedGnoFile{File: file, FileSet: fset}, nil
+	return &ParsedGnoFile{File: file, FileSet: fset, Err: err}, err
 }
 
 // ApplyChangesToAst applies the changes in the Document to the AST.
+//
+// If the new content doesn't parse, we use the partial AST rather than the
+// last good one, so that the AST's positions always match the content.
 func (d *Document) ApplyChangesToAst(path string) {
-	fset := token.NewFileSet()
-
-	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
-	if err != nil {
-		return
-	}
-
-	d.Pgf = &ParsedGnoFile{File: file}
+	d.Pgf, _ = NewParsedGnoFile(path, d.Content)
 }
 
 func (d *Document) LookupSymbol(name string, offset int) *stdlib.Symbol {
-	conf := types.Config{Importer: importer.Default(), Error: func(err error) { slog.Info(err.Error()) }}
-	info := &types.Info{
-		Defs:  make(map[*ast.Ident]types.Object),
-		Uses:  make(map[*ast.Ident]types.Object),
-		Types: make(map[ast.Expr]types.TypeAndValue),
-	}
-
-	pkg, _ := conf.Check(d.Path, d.Pgf.FileSet, []*ast.File{d.Pgf.File}, info)
-	if pkg == nil || pkg.Scope() == nil {
+	ti := d.TypeInfo()
+	if ti == nil || ti.Pkg == nil || ti.Pkg.Scope() == nil {
 		return nil
 	}
+	pkg := ti.Pkg
 	pos := d.Pgf.FileSet.File(d.Pgf.File.Pos()).Pos(offset)
 
 	inner := pkg.Scope().Innermost(pos)
@@ -2,7 +2,11 @@ package store
 
 import (
 	"errors"
+	"go/ast"
+	"go/token"
 	"strings"
+	"sync"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"go.lsp.dev/protocol"
@@ -15,6 +19,9 @@ type Document struct {
 	Content string
 	Lines   []string
 	Pgf     *ParsedGnoFile
+
+	mu       sync.Mutex
+	typeInfo *TypeInfo // cached; see TypeInfo
 }
 
 type HoveredToken struct {
@@ -27,6 +34,84 @@ func (d *Document) ApplyChanges(changes []protocol.TextDocumentContentChangeEven
 	d.Content = changes[0].Text
 	d.Lines = strings.SplitAfter(d.Content, "\n")
 	d.ApplyChangesToAst(d.Path)
+
+	d.mu.Lock()
+	d.typeInfo = nil
+	d.mu.Unlock()
+}
+
+// PosToPosition converts a position in the document's AST to an LSP position
+// (zero-based, in UTF-16 code units).
+func (d *Document) PosToPosition(pos token.Pos) protocol.Position {
+	p := d.Pgf.FileSet.Position(pos)
+	return d.LineColToPosition(p.Line, p.Column)
+}
+
+// NodeRange returns the LSP range spanned by the given AST node.
+func (d *Document) NodeRange(n ast.Node) protocol.Range {
+	return protocol.Range{
+		Start: d.PosToPosition(n.Pos()),
+		End:   d.PosToPosition(n.End()),
+	}
+}
+
+// PositionToPos converts an LSP position to a position in the document's
+// AST. It returns token.NoPos if the position is out of range.
+func (d *Document) PositionToPos(pos protocol.Position) token.Pos {
+	if d.Pgf == nil || int(pos.Line) >= len(d.Lines) {
+		return token.NoPos
+	}
+
+	tf := d.Pgf.FileSet.File(d.Pgf.File.Pos())
+	if tf == nil {
+		return token.NoPos
+	}
+
+	offset := 0
+	for _, l := range d.Lines[:pos.Line] {
+		offset += len(l)
+	}
+	offset += utf16ToByte(d.Lines[pos.Line], int(pos.Character))
+
+	if offset > tf.Size() {
+		return token.NoPos
+	}
+	return tf.Pos(offset)
+}
+
+// LineColToPosition converts a one-based line and byte column into an LSP
+// position.
+func (d *Document) LineColToPosition(line, col int) protocol.Position {
+	return lineColToPosition(d.Lines, line, col)
+}
+
+func lineColToPosition(lines []string, line, col int) protocol.Position {
+	if line < 1 || line > len(lines) {
+		return protocol.Position{}
+	}
+
+	text := lines[line-1]
+	if col-1 > len(text) {
+		col = len(text) + 1
+	}
+
+	return protocol.Position{
+		Line:      uint32(line - 1),
+		Character: uint32(len(utf16.Encode([]rune(text[:col-1])))),
+	}
+}
+
+// utf16ToByte converts a UTF-16 offset within the given line to a byte
+// offset.
+func utf16ToByte(line string, units int) int {
+	count := 0
+	for i, r := range line {
+		if count >= units {
+			return i
+		}
+		count += len(utf16.Encode([]rune{r}))
+	}
+	return len(line)
 }
 
 func (d *Document) SpanToRange(start, _ int) protocol.Range {
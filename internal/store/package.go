@@ -0,0 +1,137 @@
+package store
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.lsp.dev/protocol"
+)
+
+var moduleRe = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// A Package is the set of Gno files in a single directory.
+type Package struct {
+	Dir        string
+	ImportPath string // from `gno.mod`, if any
+	FileSet    *token.FileSet
+	Files      map[string]*ast.File // keyed by path
+	Sources    map[string]string    // keyed by path
+
+	once     sync.Once
+	typeInfo *TypeInfo
+}
+
+// LoadPackage parses every Gno file in the given directory.
+//
+// Opened documents take precedence over their on-disk content, so unsaved
+// changes are reflected. Files that fail to parse are still included if the
+// parser was able to recover a partial AST.
+func (s *DocumentStore) LoadPackage(dir string) (*Package, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &Package{
+		Dir:     dir,
+		FileSet: token.NewFileSet(),
+		Files:   map[string]*ast.File{},
+		Sources: map[string]string{},
+	}
+
+	if mod, modErr := os.ReadFile(filepath.Join(dir, "gno.mod")); modErr == nil {
+		if m := moduleRe.FindSubmatch(mod); m != nil {
+			pkg.ImportPath = string(m[1])
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".gno" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		var src string
+		if doc, ok := s.documents.Get(path); ok {
+			src = doc.Content
+		} else {
+			data, readErr := os.ReadFile(path)
+			if readErr != nil {
+				return nil, readErr
+			}
+			src = string(data)
+		}
+
+		file, parseErr := parser.ParseFile(pkg.FileSet, path, src, parser.ParseComments)
+		if parseErr != nil {
+			slog.Warn("parse_err", "path", path, "err", parseErr)
+		}
+
+		if file != nil {
+			pkg.Files[path] = file
+			pkg.Sources[path] = src
+		}
+	}
+
+	return pkg, nil
+}
+
+// Paths returns the package's file paths in sorted order.
+func (p *Package) Paths() []string {
+	paths := make([]string, 0, len(p.Files))
+	for path := range p.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// TypeInfo type-checks the package's non-test files, caching the result.
+func (p *Package) TypeInfo() *TypeInfo {
+	p.once.Do(func() {
+		files := []*ast.File{}
+		for _, path := range p.Paths() {
+			if !IsTestFile(path) {
+				files = append(files, p.Files[path])
+			}
+		}
+		p.typeInfo = checkFiles(p.Dir, p.FileSet, files)
+	})
+	return p.typeInfo
+}
+
+// FileOf returns the path of the file containing the given position.
+func (p *Package) FileOf(pos token.Pos) string {
+	if tf := p.FileSet.File(pos); tf != nil {
+		return tf.Name()
+	}
+	return ""
+}
+
+// Range returns the LSP range spanned by the given node.
+func (p *Package) Range(n ast.Node) protocol.Range {
+	return protocol.Range{
+		Start: p.Position(n.Pos()),
+		End:   p.Position(n.End()),
+	}
+}
+
+// Position converts a position in the package's AST to an LSP position.
+func (p *Package) Position(pos token.Pos) protocol.Position {
+	position := p.FileSet.Position(pos)
+	lines := strings.SplitAfter(p.Sources[position.Filename], "\n")
+	return lineColToPosition(lines, position.Line, position.Column)
+}
+
+// IsTestFile reports whether the given path is a Gno test or filetest.
+func IsTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.gno") || strings.HasSuffix(path, "_filetest.gno")
+}
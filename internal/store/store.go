@@ -2,6 +2,7 @@ package store
 
 import (
 	"log/slog"
+	"os"
 	"strings"
 
 	"go.lsp.dev/protocol"
@@ -46,8 +47,10 @@ func (s *DocumentStore) DidOpen(params protocol.DidOpenTextDocumentParams) (*Doc
 	return doc, nil
 }
 
-func (s *DocumentStore) Close(uri protocol.DocumentURI) {
-	s.documents.Remove(uri.Filename())
+func (s *DocumentStore) Close(docuri protocol.DocumentURI) {
+	if path, err := s.normalizePath(docuri); err == nil {
+		s.documents.Remove(path)
+	}
 }
 
 func (s *DocumentStore) Get(docuri uri.URI) (*Document, bool) {
@@ -59,10 +62,34 @@ func (s *DocumentStore) Get(docuri uri.URI) (*Document, bool) {
 	return d, ok
 }
 
-func (s *DocumentStore) normalizePath(docuri uri.URI) (string, error) {
-	path, err := uriToPath(docuri)
+// LoadDocument returns the document at the given path: the opened one, if
+// any, or its on-disk content otherwise. On-disk documents aren't added to
+// the store.
+func (s *DocumentStore) LoadDocument(path string) (*Document, error) {
+	if doc, ok := s.documents.Get(path); ok {
+		return doc, nil
+	}
+
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return canonical(path)
+	content := string(data)
+
+	pgf, parseErr := NewParsedGnoFile(path, content)
+	if parseErr != nil {
+		slog.Warn("parse_err", "err", parseErr)
+	}
+
+	return &Document{
+		URI:     uri.File(path),
+		Path:    path,
+		Content: content,
+		Lines:   strings.SplitAfter(content, "\n"),
+		Pgf:     pgf,
+	}, nil
+}
+
+func (s *DocumentStore) normalizePath(docuri uri.URI) (string, error) {
+	return CanonicalPath(docuri)
 }
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"errors"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+var errNoImport = errors.New("import not available")
+
+// gnoImporter resolves the Go packages that Gno mirrors (`strings`,
+// `strconv`, ...), caching the results across documents.
+//
+// Gno-only packages (`std`, `gno.land/...`) fail fast: the type checker
+// records them as fake packages, so their members are untyped but the rest of
+// the file is still checked.
+type gnoImporter struct {
+	mu     sync.Mutex
+	base   types.Importer
+	failed map[string]bool
+}
+
+var sharedImporter = &gnoImporter{
+	base:   importer.Default(),
+	failed: map[string]bool{},
+}
+
+func (i *gnoImporter) Import(path string) (*types.Package, error) {
+	if path == "std" || strings.Contains(path, ".") {
+		return nil, errNoImport
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.failed[path] {
+		return nil, errNoImport
+	}
+
+	pkg, err := i.base.Import(path)
+	if err != nil {
+		i.failed[path] = true
+	}
+	return pkg, err
+}
+
+// TypeInfo holds the result of type-checking a single document.
+type TypeInfo struct {
+	Pkg  *types.Package
+	Info *types.Info
+}
+
+// checkFiles type-checks the given files as a single package, ignoring (but
+// logging) any errors.
+func checkFiles(path string, fset *token.FileSet, files []*ast.File) *TypeInfo {
+	conf := types.Config{
+		Importer: sharedImporter,
+		Error:    func(err error) { slog.Debug("type_check", "err", err) },
+	}
+
+	info := &types.Info{
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Implicits:  make(map[ast.Node]types.Object),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+
+	pkg, _ := conf.Check(path, fset, files, info)
+	return &TypeInfo{Pkg: pkg, Info: info}
+}
+
+// TypeInfo type-checks the document, caching the result until its content
+// changes. It returns nil if the document couldn't be parsed.
+func (d *Document) TypeInfo() *TypeInfo {
+	if d.Pgf == nil || d.Pgf.FileSet == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.typeInfo == nil {
+		d.typeInfo = checkFiles(d.Path, d.Pgf.FileSet, []*ast.File{d.Pgf.File})
+	}
+	return d.typeInfo
+}
+
+// ObjectOf returns the object denoted by the given identifier, if known.
+func (ti *TypeInfo) ObjectOf(id *ast.Ident) types.Object {
+	if obj := ti.Info.Defs[id]; obj != nil {
+		return obj
+	}
+	return ti.Info.Uses[id]
+}
@@ -30,6 +30,23 @@ func uriToPath(docuri uri.URI) (string, error) {
 	return parsed.Path, nil
 }
 
+// CanonicalPath returns the path of the given URI in the form the store
+// uses for its documents (and their packages). The file itself doesn't need
+// to exist, as long as its directory does (e.g., once it's been deleted).
+func CanonicalPath(docuri uri.URI) (string, error) {
+	path, err := uriToPath(docuri)
+	if err != nil {
+		return "", err
+	}
+
+	if resolved, resolveErr := canonical(path); resolveErr == nil {
+		return resolved, nil
+	}
+
+	dir, err := canonical(filepath.Dir(path))
+	return filepath.Join(dir, filepath.Base(path)), err
+}
+
 func canonical(path string) (string, error) {
 	path = filepath.Clean(path)
 
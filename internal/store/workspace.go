@@ -0,0 +1,38 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadWorkspace loads every Gno package under the given root directory.
+//
+// Hidden directories (e.g., `.git`) are skipped.
+func (s *DocumentStore) LoadWorkspace(root string) ([]*Package, error) {
+	pkgs := []*Package{}
+
+	// Match the paths of opened documents, which are canonical.
+	root, _ = canonical(root)
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if !d.IsDir() {
+			return nil
+		} else if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		pkg, loadErr := s.LoadPackage(path)
+		if loadErr != nil {
+			return loadErr
+		} else if len(pkg.Files) > 0 {
+			pkgs = append(pkgs, pkg)
+		}
+
+		return nil
+	})
+
+	return pkgs, err
+}
@@ -0,0 +1,3 @@
+module gno.land/r/demo/counter
+
+require gno.land/p/demo/util v0.0.0-latest